@@ -5,6 +5,7 @@ go 1.24.2
 require (
 	github.com/stretchr/testify v1.8.4
 	github.com/xuri/excelize/v2 v2.9.0
+	golang.org/x/text v0.19.0
 )
 
 require (
@@ -17,6 +18,5 @@ require (
 	github.com/xuri/nfp v0.0.0-20240318013403-ab9948c2c4a7 // indirect
 	golang.org/x/crypto v0.28.0 // indirect
 	golang.org/x/net v0.30.0 // indirect
-	golang.org/x/text v0.19.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
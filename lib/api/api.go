@@ -8,8 +8,8 @@ package api
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 
+	"pncheck/lib/i18n"
 	"pncheck/lib/input"
 )
 
@@ -79,7 +79,7 @@ func JSONParse(body []byte) (*APIResponse, error) {
 	// レスポンス解析
 	var resp APIResponse
 	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("JSONパースに失敗しました: %s, %w", body, err)
+		return nil, i18n.Errorf("JSONパースに失敗しました: %s, %w", body, err)
 	}
 	// fmt.Printf("[DEBUG] pnresponse %#v\n", resp)
 	return &resp, nil
@@ -1,43 +1,80 @@
 package lib
 
 import (
-	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath" // ヘルプメッセージ用にインポート
+	"slices"
+	"strings"
+
+	"pncheck/lib/config"
+	"pncheck/lib/i18n"
 	"pncheck/lib/input"
 )
 
+// Options : コマンドライン引数の解析結果
+type Options struct {
+	FilePaths    []string  // 処理対象のExcelファイルパス
+	VerboseLevel int       // 冗長出力のレベル
+	ConfigPath   string    // 設定ファイルのパス (空文字なら実行ファイルと同じディレクトリのpncheck.json)
+	ListRules    bool      // ローカル検証ルールの一覧を表示して終了する
+	Fix          bool      // 修正版の要求票(<name>.pncheck.xlsx)を作成する
+	CSVPath      string    // 指摘の一覧を書き出すCSVファイルのパス (空文字なら書き出さない)
+	Lang         i18n.Lang // メッセージの言語
+}
+
 // ParseArguments はコマンドライン引数を解析し、処理対象のExcelファイルパスのリストを返します。
 // 引数が指定されていない場合や、-h / --help が指定された場合はヘルプメッセージを表示して終了します。
-func ParseArguments(version string) (filePaths []string, verboseLevel int, err error) {
+func ParseArguments(version string) (opts Options, err error) {
+	// ヘルプメッセージも翻訳するため、言語だけはフラグの定義より前に決める
+	if opts.Lang, err = i18n.Detect(langArg(os.Args[1:]), os.Getenv("LANG")); err != nil {
+		return
+	}
+	i18n.SetLang(opts.Lang)
+
 	// ヘルプフラグの定義
 	var showHelp bool
-	flag.BoolVar(&showHelp, "h", false, "ヘルプメッセージを表示します")
-	flag.BoolVar(&showHelp, "help", false, "ヘルプメッセージを表示します")
+	flag.BoolVar(&showHelp, "h", false, i18n.T("ヘルプメッセージを表示します"))
+	flag.BoolVar(&showHelp, "help", false, i18n.T("ヘルプメッセージを表示します"))
 
 	// バージョンフラグの定義
 	var showVersion bool
-	flag.BoolVar(&showVersion, "v", false, "バージョン情報を表示します")
-	flag.BoolVar(&showVersion, "version", false, "バージョン情報を表示します")
+	flag.BoolVar(&showVersion, "v", false, i18n.T("バージョン情報を表示します"))
+	flag.BoolVar(&showVersion, "version", false, i18n.T("バージョン情報を表示します"))
 
 	// 冗長出力
 	var verbose1 bool
-	flag.BoolVar(&verbose1, "V", false, "-Vの内容に加え、PNSearch APIの戻り値を表示します")
+	flag.BoolVar(&verbose1, "V", false, i18n.T("-Vの内容に加え、PNSearch APIの戻り値を表示します"))
 
 	// API出力ログ
 	var verbose2 bool
-	flag.BoolVar(&verbose2, "VV", false, "-VVの内容に加え、Excelシートの内容を表示します")
+	flag.BoolVar(&verbose2, "VV", false, i18n.T("-VVの内容に加え、Excelシートの内容を表示します"))
 
 	// Excel入力ログ
 	var verbose3 bool
-	flag.BoolVar(&verbose3, "VVV", false, "Excelシートへの入力を表示します")
+	flag.BoolVar(&verbose3, "VVV", false, i18n.T("Excelシートへの入力を表示します"))
+
+	// 設定ファイル
+	flag.StringVar(&opts.ConfigPath, "config", "", i18n.T("設定ファイルのパスを指定します (既定: 実行ファイルと同じディレクトリの%s)", config.DefaultFileName))
+
+	// ルール一覧
+	flag.BoolVar(&opts.ListRules, "rules", false, i18n.T("設定を反映したローカル検証ルールのID、重大度、説明を表示します"))
+
+	// 修正版の作成
+	flag.BoolVar(&opts.Fix, "fix", false, i18n.T("明細の並べ替え、隠し列の削除、文字列の正規化をした修正版を<ファイル名>%sとして保存します。元のファイルは変更しません", input.FixedFileExt))
+
+	// 指摘の一覧
+	flag.StringVar(&opts.CSVPath, "csv", "", i18n.T("すべてのファイルの指摘を1行1件で指定したCSVファイルに書き出します"))
+
+	// 言語 (値は langArg で取り出し済み。ヘルプに表示するために定義する)
+	flag.String("lang", "", i18n.T("メッセージの言語を ja か en で指定します (既定: 環境変数LANGがenで始まればen、それ以外はja)"))
 
 	// 使用法メッセージのカスタマイズ
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "指定されたExcelファイルをPNSearch APIでチェックします。\n\n")
-		fmt.Fprintf(os.Stderr, "Usage: %s [オプション] <Excelファイルパス1> [Excelファイルパス2] ...\n", filepath.Base(os.Args[0]))
+		fmt.Fprint(os.Stderr, i18n.T("指定されたExcelファイルをPNSearch APIでチェックします。\n\n"))
+		fmt.Fprint(os.Stderr, i18n.T("Usage: %s [オプション] <Excelファイルパス1> [Excelファイルパス2] ...\n", filepath.Base(os.Args[0])))
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults() // 定義されたフラグの説明を表示
 		fmt.Fprintf(os.Stderr, "\nExample:\n")
@@ -65,12 +102,19 @@ func ParseArguments(version string) (filePaths []string, verboseLevel int, err e
 	}
 
 	// フラグ以外の引数（ファイルパス）を取得
-	filePaths = flag.Args()
+	// 修正版(<name>.pncheck.xlsx)はpncheckが作ったファイルなので確認しない
+	opts.FilePaths = slices.DeleteFunc(flag.Args(), func(p string) bool {
+		if !strings.HasSuffix(strings.ToLower(p), input.FixedFileExt) {
+			return false
+		}
+		slog.Warn(i18n.T("修正版のファイルは確認しません"), slog.String("file", p))
+		return true
+	})
 
 	// ファイルパスが1つも指定されていない場合はエラー
-	if len(filePaths) == 0 {
+	if len(opts.FilePaths) == 0 && !opts.ListRules {
 		flag.Usage() // 使い方も表示
-		err = errors.New("処理対象のExcelファイルを最低1つ指定してください")
+		err = i18n.Errorf("処理対象のExcelファイルを最低1つ指定してください")
 		return
 	}
 
@@ -78,14 +122,35 @@ func ParseArguments(version string) (filePaths []string, verboseLevel int, err e
 	// processExcelFile内でエラーハンドリングするため、ここでは必須としない。
 
 	if verbose1 {
-		verboseLevel = 1
+		opts.VerboseLevel = 1
 	}
 	if verbose2 {
-		verboseLevel = 2
+		opts.VerboseLevel = 2
 	}
 	if verbose3 {
-		verboseLevel = 3
+		opts.VerboseLevel = 3
 	}
 
 	return
 }
+
+// langArg : コマンドライン引数から -lang の値を取り出す。指定がなければ空文字
+// -lang=en, --lang=en, -lang en の形式に対応する
+func langArg(args []string) string {
+	for i, a := range args {
+		if a == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if !strings.HasPrefix(a, "-") || name != "lang" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
@@ -8,6 +8,7 @@ import (
 )
 
 func TestParseArguments(t *testing.T) {
+	t.Setenv("LANG", "ja_JP.UTF-8") // メッセージの言語を日本語に固定する
 	oldArgs := os.Args
 	// flag.CommandLine の元の状態を保存 (テスト全体で1回)
 	originalCommandLine := flag.CommandLine
@@ -38,6 +39,20 @@ func TestParseArguments(t *testing.T) {
 			wantVerboseLevel: 0,
 			wantErr:          false,
 		},
+		{
+			name:             "正常系 - 修正版はスキップ",
+			args:             []string{"testapp", "file1.xlsx", "file1.pncheck.xlsx"},
+			wantPaths:        []string{"file1.xlsx"},
+			wantVerboseLevel: 0,
+			wantErr:          false,
+		},
+		{
+			name:             "異常系 - 修正版だけ",
+			args:             []string{"testapp", "file1.pncheck.xlsx"},
+			wantPaths:        []string{},
+			wantVerboseLevel: 0,
+			wantErr:          true,
+		},
 		{
 			name:             "異常系 - 引数なし",
 			args:             []string{"testapp"},
@@ -56,7 +71,8 @@ func TestParseArguments(t *testing.T) {
 			// flag.ContinueOnError を使うか、エラーをハンドリングする
 			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError) // または flag.PanicOnError
 
-			gotPaths, gotVerboseLevel, err := ParseArguments("v0.1.0") // ここで flag.Parse() が呼ばれる
+			gotOpts, err := ParseArguments("v0.1.0") // ここで flag.Parse() が呼ばれる
+			gotPaths, gotVerboseLevel := gotOpts.FilePaths, gotOpts.VerboseLevel
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ParseArguments() error = %v, wantErr %v", err, tt.wantErr)
@@ -136,6 +152,29 @@ func TestParseArguments(t *testing.T) {
 	}...)
 }
 
+func TestLangArg(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"指定なし", []string{"file1.xlsx"}, ""},
+		{"イコールで指定", []string{"-lang=en", "file1.xlsx"}, "en"},
+		{"ハイフン2つ", []string{"-V", "--lang=ja", "file1.xlsx"}, "ja"},
+		{"次の引数で指定", []string{"-lang", "en", "file1.xlsx"}, "en"},
+		{"値がない", []string{"-lang"}, ""},
+		{"--より後はファイル名", []string{"--", "-lang=en"}, ""},
+		{"似た名前のフラグは無視", []string{"-language=en", "lang=en"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := langArg(tt.args); got != tt.want {
+				t.Errorf("langArg(%v) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
 // 注意: -h や --help のテストは、os.Exit を呼び出すため単純にはテストできません。
 // os.Exit をモック化する、またはコマンドの出力をキャプチャするような
 // より高度なテスト手法が必要になります。
@@ -0,0 +1,274 @@
+/*
+config パッケージでは、
+ユーザー設定ファイル(pncheck.json)の読み込みと既定値を扱います。
+
+設定ファイルが見つからない場合は既定値で動作します。
+*/
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"pncheck/lib/i18n"
+)
+
+const (
+	// DefaultFileName : 実行ファイルと同じディレクトリから探す設定ファイル名
+	DefaultFileName = "pncheck.json"
+)
+
+// 正規化処理の名前
+const (
+	StepNFKC      = "nfkc"      // 全角英数字を半角に、半角カナを全角にする
+	StepSpace     = "space"     // NBSPや全角スペースを半角スペースにして、連続する空白を1つにまとめる
+	StepInvisible = "invisible" // ゼロ幅スペースなどの不可視文字を取り除く
+)
+
+var (
+	// allSteps : 既定で適用する正規化処理
+	allSteps = []string{StepNFKC, StepSpace, StepInvisible}
+	// NormalizeFields : 正規化できる項目名
+	NormalizeFields = []string{"品番", "品名", "型式", "メーカ", "要望先", "単位"}
+)
+
+type (
+	// Config : pncheck.jsonの内容
+	Config struct {
+		Normalize Normalize `json:"normalize"`
+		// ルールID(sort-order など)ごとの有効・無効と重大度の設定
+		Rules map[string]Rule `json:"rules"`
+		// 部署ごとの独自ルール
+		CustomRules []CustomRule `json:"custom_rules"`
+		// 要望納期の確認
+		Deadline Deadline `json:"deadline"`
+		// 数量と単位の確認
+		Units Units `json:"units"`
+		// 明細ごとの金額の確認
+		Price Price `json:"price"`
+		// 合計金額の確認
+		Sum Sum `json:"sum"`
+		// 指摘ごとの対処方法
+		Hints Hints `json:"hints"`
+	}
+
+	// Hints : 指摘ごとの対処方法(ヒント)の設定
+	Hints struct {
+		// 既定のヒントを上書きするファイル。相対パスは設定ファイルのディレクトリから探す
+		File string `json:"file,omitempty"`
+	}
+
+	// Sum : 合計金額の確認の設定
+	Sum struct {
+		// 範囲の合計と合計セルの値の差をいくつまで一致とみなすか
+		Tolerance float64 `json:"tolerance"`
+	}
+
+	// Price : 金額(数量×予定単価)の端数処理
+	Price struct {
+		// round(四捨五入), floor(切り捨て), ceil(切り上げ), none(端数処理なし) のいずれか
+		Rounding string `json:"rounding"`
+		// 端数処理後に残す小数点以下の桁数
+		Digits int `json:"digits"`
+	}
+
+	// Units : 単位の辞書
+	Units struct {
+		// 数量が整数でなければならない単位
+		Countable []string `json:"countable"`
+		// 使える単位。空なら確認しない
+		Allowed []string `json:"allowed"`
+		// 表記ゆれと正しい単位の対応。PNSearchへ送る前に書き換える
+		Synonyms map[string]string `json:"synonyms"`
+	}
+
+	// Deadline : 要望納期の確認の設定
+	Deadline struct {
+		// 要求年月日から何年より先の要望納期を誤りとみなすか
+		MaxYears int `json:"max_years"`
+		// 要望納期が休日でないことの確認
+		Calendar Calendar `json:"calendar"`
+	}
+
+	// Calendar : 会社の休日
+	Calendar struct {
+		// trueなら土曜日と日曜日を休日とする
+		Weekend bool `json:"weekend"`
+		// 休日を1行に1日ずつ書いたファイル。相対パスは設定ファイルのディレクトリから探す
+		File string `json:"file,omitempty"`
+	}
+
+	// Normalize : PNSearchへ送る前の文字列の正規化設定
+	Normalize struct {
+		// 項目名(品番, 品名, 型式, メーカ, 要望先, 単位)ごとに適用する正規化処理
+		// 空のリストを指定するとその項目は正規化しない
+		Fields map[string][]string `json:"fields"`
+	}
+
+	// Rule : ローカル検証ルールの設定
+	Rule struct {
+		// falseならルールを実行しない。省略時は有効
+		Enabled *bool `json:"enabled,omitempty"`
+		// info, warning, error, fatal のいずれか。省略時はルールの既定の重大度
+		Severity string `json:"severity,omitempty"`
+	}
+)
+
+// 金額の端数処理
+const (
+	RoundingRound = "round"
+	RoundingFloor = "floor"
+	RoundingCeil  = "ceil"
+	RoundingNone  = "none"
+)
+
+// 独自ルールの評価単位
+const (
+	ScopeOrder  = "order"  // 入力Ⅰの明細1行ごとに評価する
+	ScopeHeader = "header" // 要求票ごとに1回評価する
+)
+
+// CustomRule : 式で定義する独自ルール
+//
+// when が真になる明細(またはヘッダー)で assert が偽になると message を表示する。
+// 式の書き方は expr パッケージを参照。
+type CustomRule struct {
+	ID       string `json:"id"`                 // ルールID。rules や .pncheckignore で指定する
+	Scope    string `json:"scope,omitempty"`    // order(既定) または header
+	When     string `json:"when,omitempty"`     // 対象を絞り込む条件。空ならすべて
+	Assert   string `json:"assert"`             // 満たすべき条件
+	Message  string `json:"message"`            // 条件を満たさないときの説明。{品番} のように項目の値を埋め込める
+	Severity string `json:"severity,omitempty"` // 既定はerror
+}
+
+// Severities : ルールに設定できる重大度
+var Severities = []string{"info", "warning", "error", "fatal"}
+
+// Default は設定ファイルがない場合の既定値を返す
+func Default() *Config {
+	fields := make(map[string][]string)
+	for _, name := range NormalizeFields {
+		fields[name] = allSteps
+	}
+	return &Config{
+		Normalize: Normalize{Fields: fields},
+		Deadline:  Deadline{MaxYears: 3},
+		Units: Units{
+			Countable: []string{"個", "本", "枚", "セット"},
+			Allowed: []string{
+				"個", "本", "枚", "セット", "式", "台", "組", "箱", "袋", "巻", "缶", "冊", "対",
+				"m", "mm", "kg", "g", "L", "mL",
+			},
+			Synonyms: map[string]string{"ヶ": "個", "ケ": "個", "コ": "個", "pcs": "個"},
+		},
+		Price: Price{Rounding: RoundingRound},
+		Sum:   Sum{Tolerance: 0.000001},
+	}
+}
+
+// Load は設定ファイルを読み込み、既定値に上書きして返す
+//
+// pathが空文字の場合は実行ファイルと同じディレクトリの pncheck.json を探し、
+// 見つからなければ既定値を返す。
+// pathを明示した場合はファイルが存在しなければエラーを返す。
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	explicit := path != ""
+	if !explicit {
+		exe, err := os.Executable()
+		if err != nil {
+			return cfg, nil
+		}
+		path = filepath.Join(filepath.Dir(exe), DefaultFileName)
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, i18n.Errorf("設定ファイルを読み込めません '%s': %w", path, err)
+	}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, i18n.Errorf("設定ファイルのJSON解析に失敗しました '%s': %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, i18n.Errorf("設定ファイルの値が不正です '%s': %w", path, err)
+	}
+	if f := cfg.Deadline.Calendar.File; f != "" && !filepath.IsAbs(f) {
+		cfg.Deadline.Calendar.File = filepath.Join(filepath.Dir(path), f)
+	}
+	if f := cfg.Hints.File; f != "" && !filepath.IsAbs(f) {
+		cfg.Hints.File = filepath.Join(filepath.Dir(path), f)
+	}
+	return cfg, nil
+}
+
+// validate : 設定値の整合性を確認する
+func (cfg *Config) validate() error {
+	for field, steps := range cfg.Normalize.Fields {
+		if !slices.Contains(NormalizeFields, field) {
+			return i18n.Errorf("normalize.fields: 正規化できない項目名 '%s'", field)
+		}
+		for _, step := range steps {
+			switch step {
+			case StepNFKC, StepSpace, StepInvisible:
+			default:
+				return i18n.Errorf("normalize.fields.%s: 不明な正規化処理 '%s'", field, step)
+			}
+		}
+	}
+	if cfg.Deadline.MaxYears <= 0 {
+		return i18n.Errorf("deadline.max_years: 1以上を指定してください: %d", cfg.Deadline.MaxYears)
+	}
+	switch cfg.Price.Rounding {
+	case RoundingRound, RoundingFloor, RoundingCeil, RoundingNone:
+	default:
+		return i18n.Errorf("price.rounding: 不明な端数処理 '%s'", cfg.Price.Rounding)
+	}
+	if cfg.Price.Digits < 0 {
+		return i18n.Errorf("price.digits: 0以上を指定してください: %d", cfg.Price.Digits)
+	}
+	if cfg.Sum.Tolerance < 0 {
+		return i18n.Errorf("sum.tolerance: 0以上を指定してください: %g", cfg.Sum.Tolerance)
+	}
+	for from, to := range cfg.Units.Synonyms {
+		if len(cfg.Units.Allowed) > 0 && !slices.Contains(cfg.Units.Allowed, to) {
+			return i18n.Errorf("units.synonyms.%s: '%s' が units.allowed にありません", from, to)
+		}
+	}
+	ids := make(map[string]bool)
+	for i, r := range cfg.CustomRules {
+		switch {
+		case r.ID == "":
+			return i18n.Errorf("custom_rules[%d]: id がありません", i)
+		case ids[r.ID]:
+			return i18n.Errorf("custom_rules[%d]: id '%s' が重複しています", i, r.ID)
+		case r.Assert == "":
+			return i18n.Errorf("custom_rules.%s: assert がありません", r.ID)
+		case r.Message == "":
+			return i18n.Errorf("custom_rules.%s: message がありません", r.ID)
+		case r.Scope != "" && r.Scope != ScopeOrder && r.Scope != ScopeHeader:
+			return i18n.Errorf("custom_rules.%s: 不明な scope '%s'", r.ID, r.Scope)
+		case r.Severity != "" && !slices.Contains(Severities, r.Severity):
+			return i18n.Errorf("custom_rules.%s: 不明な重大度 '%s'", r.ID, r.Severity)
+		}
+		ids[r.ID] = true
+	}
+	for id, rule := range cfg.Rules {
+		if rule.Severity != "" && !slices.Contains(Severities, rule.Severity) {
+			return i18n.Errorf("rules.%s: 不明な重大度 '%s'", id, rule.Severity)
+		}
+	}
+	return nil
+}
+
+// RuleEnabled はルールIDが設定で無効化されていなければtrueを返す
+func (cfg *Config) RuleEnabled(id string) bool {
+	r, ok := cfg.Rules[id]
+	return !ok || r.Enabled == nil || *r.Enabled
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	writeConfig := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), DefaultFileName)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("既定値に上書きされる", func(t *testing.T) {
+		path := writeConfig(t, `{"normalize": {"fields": {"品名": []}}}`)
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(cfg.Normalize.Fields["品名"]) != 0 {
+			t.Errorf("品名の正規化が無効になっていません: %v", cfg.Normalize.Fields["品名"])
+		}
+		if len(cfg.Normalize.Fields["品番"]) != len(allSteps) {
+			t.Errorf("品番の正規化が既定値のままではありません: %v", cfg.Normalize.Fields["品番"])
+		}
+	})
+
+	t.Run("不明な正規化処理はエラー", func(t *testing.T) {
+		path := writeConfig(t, `{"normalize": {"fields": {"品番": ["upper"]}}}`)
+		if _, err := Load(path); err == nil {
+			t.Error("不明な正規化処理でエラーが返されませんでした")
+		}
+	})
+
+	t.Run("ルールの無効化と重大度の変更", func(t *testing.T) {
+		path := writeConfig(t, `{"rules": {"sort-order": {"severity": "warning"}, "hidden-column": {"enabled": false}}}`)
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if got := cfg.Rules["sort-order"].Severity; got != "warning" {
+			t.Errorf("sort-order の重大度 = %q, want warning", got)
+		}
+		if cfg.RuleEnabled("hidden-column") {
+			t.Error("hidden-column が無効になっていません")
+		}
+		if !cfg.RuleEnabled("excel-sum") {
+			t.Error("設定のないルールが無効になっています")
+		}
+	})
+
+	t.Run("不明な重大度はエラー", func(t *testing.T) {
+		path := writeConfig(t, `{"rules": {"sort-order": {"severity": "critical"}}}`)
+		if _, err := Load(path); err == nil {
+			t.Error("不明な重大度でエラーが返されませんでした")
+		}
+	})
+
+	t.Run("独自ルールの必須項目と重複", func(t *testing.T) {
+		for _, content := range []string{
+			`{"custom_rules": [{"id": "qty", "message": "m"}]}`,
+			`{"custom_rules": [{"id": "qty", "assert": "数量 > 0"}]}`,
+			`{"custom_rules": [{"id": "qty", "assert": "数量 > 0", "message": "m", "scope": "row"}]}`,
+			`{"custom_rules": [{"id": "qty", "assert": "数量 > 0", "message": "m"}, {"id": "qty", "assert": "true", "message": "m"}]}`,
+		} {
+			if _, err := Load(writeConfig(t, content)); err == nil {
+				t.Errorf("不正な独自ルールでエラーが返されませんでした: %s", content)
+			}
+		}
+	})
+
+	t.Run("休日ファイルは設定ファイルのディレクトリから探す", func(t *testing.T) {
+		path := writeConfig(t, `{"deadline": {"calendar": {"file": "calendar.txt"}}}`)
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if want := filepath.Join(filepath.Dir(path), "calendar.txt"); cfg.Deadline.Calendar.File != want {
+			t.Errorf("Calendar.File = %q, want %q", cfg.Deadline.Calendar.File, want)
+		}
+		if cfg.Deadline.MaxYears != 3 {
+			t.Errorf("MaxYears = %d, want 3", cfg.Deadline.MaxYears)
+		}
+	})
+
+	t.Run("ヒントファイルは設定ファイルのディレクトリから探す", func(t *testing.T) {
+		path := writeConfig(t, `{"hints": {"file": "hints.json"}}`)
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if want := filepath.Join(filepath.Dir(path), "hints.json"); cfg.Hints.File != want {
+			t.Errorf("Hints.File = %q, want %q", cfg.Hints.File, want)
+		}
+	})
+
+	t.Run("合計金額の許容誤差", func(t *testing.T) {
+		cfg, err := Load(writeConfig(t, `{"sum": {"tolerance": 0.5}}`))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.Sum.Tolerance != 0.5 {
+			t.Errorf("Sum.Tolerance = %g, want 0.5", cfg.Sum.Tolerance)
+		}
+		if _, err := Load(writeConfig(t, `{"sum": {"tolerance": -1}}`)); err == nil {
+			t.Error("負の許容誤差でエラーが返されませんでした")
+		}
+	})
+
+	t.Run("明示したファイルが存在しなければエラー", func(t *testing.T) {
+		if _, err := Load(filepath.Join(t.TempDir(), "none.json")); err == nil {
+			t.Error("存在しない設定ファイルでエラーが返されませんでした")
+		}
+	})
+}
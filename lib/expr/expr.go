@@ -0,0 +1,695 @@
+/*
+expr パッケージでは、
+設定ファイルで定義する独自ルールのための小さな式言語を扱います。
+
+	数量 > 0 && 単位 in ["個", "式", "m"]
+	発注区分 != "外注" || 検区 in ["A", "B"]
+	matches(品番, "^[A-Z]{2}-\\d+$")
+
+値は文字列、数値、真偽値の3種類で、識別子は Env から値を引きます。
+演算子は優先順位の低い順に
+||, &&, !, 比較(== != < <= > >= in), + -, * /, 単項マイナス です。
+文字列同士の < や > は辞書順で比較するため、YYYY/MM/DD の日付も比較できます。
+*/
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"pncheck/lib/i18n"
+)
+
+// Env : 識別子と値の対応。値は string, float64, bool のいずれか
+type Env map[string]any
+
+// Expr : 解析済みの式
+type Expr struct {
+	src  string
+	root node
+}
+
+// String は解析前の式を返します。
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Parse は式を解析します。
+func Parse(src string) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, i18n.Errorf("式 '%s': %w", src, err)
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = i18n.Errorf("%d文字目: 余分な '%s' があります", p.peek().pos, p.peek().text)
+	}
+	if err != nil {
+		return nil, i18n.Errorf("式 '%s': %w", src, err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// Idents は式が参照する識別子を出現順に重複なく返します。関数名は含みません。
+func (e *Expr) Idents() (idents []string) {
+	seen := make(map[string]bool)
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case identNode:
+			if !seen[n.name] {
+				seen[n.name] = true
+				idents = append(idents, n.name)
+			}
+		case unaryNode:
+			walk(n.x)
+		case binaryNode:
+			walk(n.x)
+			walk(n.y)
+		case listNode:
+			for _, x := range n.items {
+				walk(x)
+			}
+		case callNode:
+			for _, x := range n.args {
+				walk(x)
+			}
+		}
+	}
+	walk(e.root)
+	return
+}
+
+// Eval は式を評価して値を返します。
+func (e *Expr) Eval(env Env) (any, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return nil, i18n.Errorf("式 '%s': %w", e.src, err)
+	}
+	return v, nil
+}
+
+// EvalBool は式を評価し、真偽値でなければエラーを返します。
+func (e *Expr) EvalBool(env Env) (bool, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, i18n.Errorf("式 '%s': 結果 %v が真偽値ではありません", e.src, v)
+	}
+	return b, nil
+}
+
+// Format は値を表示用の文字列にします。
+func Format(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// 字句
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string // tokStringはクォートを外した値
+	pos  int    // 式の先頭からの文字数 (1始まり)
+}
+
+// operators : 長いものから順に照合する演算子と区切り記号
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "(", ")", "[", "]", ","}
+
+func lex(src string) (toks []token, err error) {
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		pos := utf8.RuneCountInString(src[:i]) + 1
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '"':
+			s, n, err := lexString(src[i:])
+			if err != nil {
+				return nil, i18n.Errorf("%d文字目: %w", pos, err)
+			}
+			toks = append(toks, token{tokString, s, pos})
+			i += n
+		case isDigit(src[i]) || (r == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			j := i
+			for j < len(src) && (isDigit(src[j]) || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, src[i:j], pos})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(src) {
+				r, size := utf8.DecodeRuneInString(src[j:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+					break
+				}
+				j += size
+			}
+			toks = append(toks, token{tokIdent, src[i:j], pos})
+			i = j
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, i18n.Errorf("%d文字目: 使えない文字 '%c' があります", pos, r)
+			}
+			toks = append(toks, token{tokOp, op, pos})
+			i += len(op)
+		}
+	}
+	return append(toks, token{tokEOF, "", utf8.RuneCountInString(src) + 1}), nil
+}
+
+// isDigit : 数値リテラルに使える半角数字
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// lexString : ダブルクォートで囲まれた文字列を読み、値と読んだバイト数を返す
+func lexString(s string) (string, int, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			v, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, i18n.Errorf("文字列 %s が不正です", s[:i+1])
+			}
+			return v, i + 1, nil
+		}
+	}
+	return "", 0, i18n.Errorf("文字列が閉じられていません")
+}
+
+// 構文
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept : 次の字句が演算子opなら読み進めてtrueを返す
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokOp && t.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return i18n.Errorf("%d文字目: '%s' が必要です", t.pos, op)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	x, err := p.parseAnd()
+	for err == nil && p.accept("||") {
+		var y node
+		if y, err = p.parseAnd(); err == nil {
+			x = binaryNode{"||", x, y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) parseAnd() (node, error) {
+	x, err := p.parseNot()
+	for err == nil && p.accept("&&") {
+		var y node
+		if y, err = p.parseNot(); err == nil {
+			x = binaryNode{"&&", x, y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.accept("!") {
+		x, err := p.parseNot()
+		return unaryNode{"!", x}, err
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	x, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokIdent && t.text == "in" {
+		p.next()
+		y, err := p.parseAdd()
+		return binaryNode{"in", x, y}, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			y, err := p.parseAdd()
+			return binaryNode{op, x, y}, err
+		}
+	}
+	return x, nil
+}
+
+func (p *parser) parseAdd() (node, error) {
+	x, err := p.parseMul()
+	for err == nil {
+		op := p.peek().text
+		if p.peek().kind != tokOp || (op != "+" && op != "-") {
+			break
+		}
+		p.next()
+		var y node
+		if y, err = p.parseMul(); err == nil {
+			x = binaryNode{op, x, y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) parseMul() (node, error) {
+	x, err := p.parseUnary()
+	for err == nil {
+		op := p.peek().text
+		if p.peek().kind != tokOp || (op != "*" && op != "/") {
+			break
+		}
+		p.next()
+		var y node
+		if y, err = p.parseUnary(); err == nil {
+			x = binaryNode{op, x, y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("-") {
+		x, err := p.parseUnary()
+		return unaryNode{"-", x}, err
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, i18n.Errorf("%d文字目: 数値 '%s' が不正です", t.pos, t.text)
+		}
+		return literalNode{v}, nil
+	case tokString:
+		return literalNode{t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literalNode{true}, nil
+		case "false":
+			return literalNode{false}, nil
+		}
+		if !p.accept("(") {
+			return identNode{t.text}, nil
+		}
+		fn, ok := functions[t.text]
+		if !ok {
+			return nil, i18n.Errorf("%d文字目: 不明な関数 '%s'", t.pos, t.text)
+		}
+		args, err := p.parseList(")")
+		if err != nil {
+			return nil, err
+		}
+		if len(args) != fn.arity {
+			return nil, i18n.Errorf("%d文字目: 関数 %s の引数は%d個です", t.pos, t.text, fn.arity)
+		}
+		return callNode{t.text, args}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "[":
+			items, err := p.parseList("]")
+			return listNode{items}, err
+		}
+	}
+	if t.kind == tokEOF {
+		return nil, i18n.Errorf("%d文字目: 式が途中で終わっています", t.pos)
+	}
+	return nil, i18n.Errorf("%d文字目: 予期しない '%s' があります", t.pos, t.text)
+}
+
+// parseList : カンマ区切りの式を閉じ記号closeまで読む
+func (p *parser) parseList(close string) (items []node, err error) {
+	if p.accept(close) {
+		return nil, nil
+	}
+	for {
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, x)
+		if p.accept(close) {
+			return items, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// 評価
+
+type (
+	node interface {
+		eval(env Env) (any, error)
+	}
+	literalNode struct{ v any }
+	identNode   struct{ name string }
+	unaryNode   struct {
+		op string
+		x  node
+	}
+	binaryNode struct {
+		op   string
+		x, y node
+	}
+	listNode struct{ items []node }
+	callNode struct {
+		name string
+		args []node
+	}
+)
+
+func (n literalNode) eval(Env) (any, error) {
+	return n.v, nil
+}
+
+func (n identNode) eval(env Env) (any, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, i18n.Errorf("不明な項目名 '%s'", n.name)
+	}
+	return v, nil
+}
+
+func (n unaryNode) eval(env Env) (any, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, i18n.Errorf("'!' の対象 %v が真偽値ではありません", Format(v))
+		}
+		return !b, nil
+	default: // "-"
+		f, err := toNumber(v)
+		return -f, err
+	}
+}
+
+func (n binaryNode) eval(env Env) (any, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	// 論理演算は短絡評価する
+	if n.op == "&&" || n.op == "||" {
+		b, ok := x.(bool)
+		if !ok {
+			return nil, i18n.Errorf("'%s' の左辺 %v が真偽値ではありません", n.op, Format(x))
+		}
+		if (n.op == "&&" && !b) || (n.op == "||" && b) {
+			return b, nil
+		}
+		y, err := n.y.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if b, ok = y.(bool); !ok {
+			return nil, i18n.Errorf("'%s' の右辺 %v が真偽値ではありません", n.op, Format(y))
+		}
+		return b, nil
+	}
+
+	if n.op == "in" {
+		list, ok := n.y.(listNode)
+		if !ok {
+			return nil, i18n.Errorf("'in' の右辺はリスト [...] で指定してください")
+		}
+		for _, item := range list.items {
+			y, err := item.eval(env)
+			if err != nil {
+				return nil, err
+			}
+			if equal(x, y) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	y, err := n.y.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(x, y), nil
+	case "!=":
+		return !equal(x, y), nil
+	case "<", "<=", ">", ">=":
+		c, err := compare(x, y)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	}
+
+	// 算術演算。+ は文字列同士なら連結する
+	if xs, ok := x.(string); ok && n.op == "+" {
+		if ys, ok := y.(string); ok {
+			return xs + ys, nil
+		}
+	}
+	a, err := toNumber(x)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toNumber(y)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	default:
+		if b == 0 {
+			return nil, i18n.Errorf("0で割ることはできません")
+		}
+		return a / b, nil
+	}
+}
+
+func (n listNode) eval(Env) (any, error) {
+	return nil, i18n.Errorf("リストは 'in' の右辺にだけ書けます")
+}
+
+func (n callNode) eval(env Env) (any, error) {
+	args := make([]any, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return functions[n.name].call(args)
+}
+
+// equal : 数値同士は数値として、それ以外は表示用の文字列として比較する
+func equal(x, y any) bool {
+	a, aok := x.(float64)
+	b, bok := y.(float64)
+	if aok && bok {
+		return a == b
+	}
+	if aok != bok {
+		// 数値と文字列は、文字列が数値として解釈できれば数値として比較する
+		if a, err := toNumber(x); err == nil {
+			if b, err := toNumber(y); err == nil {
+				return a == b
+			}
+		}
+	}
+	return Format(x) == Format(y)
+}
+
+// compare : 大小比較。両方文字列なら辞書順、それ以外は数値として比較する
+func compare(x, y any) (int, error) {
+	xs, xok := x.(string)
+	ys, yok := y.(string)
+	if xok && yok {
+		return strings.Compare(xs, ys), nil
+	}
+	a, err := toNumber(x)
+	if err != nil {
+		return 0, err
+	}
+	b, err := toNumber(y)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case a < b:
+		return -1, nil
+	case a > b:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// toNumber : 数値か、数値として解釈できる文字列を数値にする
+func toNumber(v any) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, nil
+		}
+	}
+	return 0, i18n.Errorf("%q は数値ではありません", Format(v))
+}
+
+// 関数
+
+type function struct {
+	arity int
+	call  func(args []any) (any, error)
+}
+
+// functions : 式で使える関数
+var functions = map[string]function{
+	// empty(x) : 空文字または0ならtrue
+	"empty": {1, func(args []any) (any, error) {
+		s := Format(args[0])
+		return s == "" || s == "0", nil
+	}},
+	// len(s) : 文字数
+	"len": {1, func(args []any) (any, error) {
+		return float64(utf8.RuneCountInString(Format(args[0]))), nil
+	}},
+	// contains(s, sub) : sにsubが含まれればtrue
+	"contains": {2, func(args []any) (any, error) {
+		return strings.Contains(Format(args[0]), Format(args[1])), nil
+	}},
+	// prefix(s, p) : sがpで始まればtrue
+	"prefix": {2, func(args []any) (any, error) {
+		return strings.HasPrefix(Format(args[0]), Format(args[1])), nil
+	}},
+	// matches(s, re) : sが正規表現reに一致すればtrue
+	"matches": {2, func(args []any) (any, error) {
+		re, err := compileRegexp(Format(args[1]))
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(Format(args[0])), nil
+	}},
+	// integer(x) : xが整数ならtrue
+	"integer": {1, func(args []any) (any, error) {
+		f, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return f == float64(int64(f)), nil
+	}},
+}
+
+// regexpCache : 明細の行ごとに同じ正規表現をコンパイルしないためのキャッシュ
+var regexpCache = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+func compileRegexp(s string) (*regexp.Regexp, error) {
+	regexpCache.Lock()
+	defer regexpCache.Unlock()
+	if re, ok := regexpCache.m[s]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, i18n.Errorf("正規表現 '%s' が不正です: %w", s, err)
+	}
+	regexpCache.m[s] = re
+	return re, nil
+}
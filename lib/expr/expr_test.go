@@ -0,0 +1,109 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	env := Env{
+		"数量":   3.0,
+		"単位":   "個",
+		"検区":   "A",
+		"発注区分": "外注",
+		"品番":   "AB-123",
+		"要望納期": "2025/04/10",
+		"予定単価": 0.0,
+		"要望先":  "",
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want any
+	}{
+		{"数値の比較", "数量 > 0", true},
+		{"算術と優先順位", "数量 * 2 + 1 == 7", true},
+		{"単項マイナス", "-数量 < 0", true},
+		{"in", `単位 in ["個", "式", "m"]`, true},
+		{"inに含まれない", `単位 in ["式", "m"]`, false},
+		{"論理演算", `発注区分 != "外注" || 検区 in ["A", "B"]`, true},
+		{"否定と括弧", `!(数量 > 0 && 単位 == "個")`, false},
+		{"文字列の辞書順で日付を比較", `要望納期 >= "2025/04/01"`, true},
+		{"文字列の連結", `単位 + "/" + 検区`, "個/A"},
+		{"数値と数値の文字列", `数量 == "3"`, true},
+		{"empty", "empty(要望先) && empty(予定単価)", true},
+		{"len", "len(品番)", 6.0},
+		{"matches", `matches(品番, "^[A-Z]{2}-\\d+$")`, true},
+		{"contains", `contains(品番, "-")`, true},
+		{"prefix", `prefix(品番, "XY")`, false},
+		{"integer", "integer(数量 / 2)", false},
+		{"短絡評価で右辺を評価しない", `false && 存在しない項目 == 1`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.src, err)
+			}
+			got, err := e.Eval(env)
+			if err != nil {
+				t.Fatalf("Eval(%q) error = %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval(%q) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	tests := []string{
+		"",
+		"数量 >",
+		"(数量 > 0",
+		`単位 == "個`,
+		"数量 > 0 0",
+		"unknown(数量)",
+		"len(数量, 1)",
+		"数量 # 0",
+	}
+	for _, src := range tests {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q) error = nil, want error", src)
+		}
+	}
+}
+
+func TestEval_Error(t *testing.T) {
+	env := Env{"数量": 1.0, "単位": "個"}
+	tests := []string{
+		"存在しない項目 == 1",
+		"単位 > 0",
+		"数量 / 0",
+		"数量 in 単位",
+		`matches(単位, "[")`,
+		"数量 && true",
+	}
+	for _, src := range tests {
+		e, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", src, err)
+		}
+		if _, err := e.EvalBool(env); err == nil {
+			t.Errorf("EvalBool(%q) error = nil, want error", src)
+		}
+	}
+}
+
+func TestIdents(t *testing.T) {
+	e, err := Parse(`数量 > 0 && (単位 in ["個", 既定単位] || empty(数量))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"数量", "単位", "既定単位"}
+	if got := e.Idents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Idents() = %v, want %v", got, want)
+	}
+}
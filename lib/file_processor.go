@@ -8,13 +8,19 @@ package lib
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"pncheck/lib/api"
+	"pncheck/lib/config"
+	"pncheck/lib/hint"
+	"pncheck/lib/i18n"
 	"pncheck/lib/input"
 	"pncheck/lib/output"
 )
@@ -24,7 +30,10 @@ import (
 // @errors:
 //
 //	Reports.Classify(): unknown status code %d: must 200 <= code < 600
-func ProcessExcelFiles(filePaths []string, debugLevel int) (output.Reports, error) {
+//
+// fixがtrueなら、各ファイルの修正版(<name>.pncheck.xlsx)を作成し、元のファイルは変更しません。
+// 指摘にはhintsから探した対処方法を添えます。
+func ProcessExcelFiles(filePaths []string, debugLevel int, cfg *config.Config, engine *input.Engine, hints *hint.Catalog, fix bool) (output.Reports, error) {
 	var (
 		reports  output.Reports
 		fileChan = make(chan string, len(filePaths))
@@ -40,6 +49,12 @@ func ProcessExcelFiles(filePaths []string, debugLevel int) (output.Reports, erro
 
 	resultChan := make(chan output.Report, len(filePaths))
 
+	// ファイルをまたぐ確認のため、読み込めた要求票を集める
+	var (
+		mu    sync.Mutex
+		files []input.FileSheet
+	)
+
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
@@ -47,7 +62,13 @@ func ProcessExcelFiles(filePaths []string, debugLevel int) (output.Reports, erro
 			defer wg.Done()
 			for filePath := range fileChan {
 				sem <- true
-				processFile(filePath, resultChan, debugLevel)
+				report, sheet := processFile(filePath, debugLevel, cfg, engine, fix)
+				resultChan <- report
+				if sheet != nil {
+					mu.Lock()
+					files = append(files, input.FileSheet{Path: filePath, Sheet: sheet})
+					mu.Unlock()
+				}
 				<-sem
 			}
 		}(i)
@@ -58,7 +79,14 @@ func ProcessExcelFiles(filePaths []string, debugLevel int) (output.Reports, erro
 		close(resultChan)
 	}()
 
+	var results []output.Report
 	for result := range resultChan {
+		results = append(results, result)
+	}
+	applyCrossFileFindings(results, files, engine)
+	applyHints(results, hints)
+
+	for _, result := range results {
 		if err := reports.Classify(result); err != nil {
 			return reports, err
 		}
@@ -67,154 +95,457 @@ func ProcessExcelFiles(filePaths []string, debugLevel int) (output.Reports, erro
 	return reports, nil
 }
 
-// formatErrorMessage はErrorRecordを整形して文字列として返します。
-func formatErrorMessage(e api.ErrorRecord) string {
-	var parts []string
-	if e.Details != "" {
-		parts = append(parts, e.Details)
+// applyCrossFileFindings はファイルをまたぐ重複を探し、
+// 該当するファイルのレポートに指摘を追加してステータスを更新します。
+func applyCrossFileFindings(results []output.Report, files []input.FileSheet, engine *input.Engine) {
+	// 並列処理の完了順によらず、実行ごとにメッセージの順序が変わらないようにする
+	slices.SortFunc(files, func(a, b input.FileSheet) int { return strings.Compare(a.Path, b.Path) })
+	duplicates := input.FindDuplicatesAcrossFiles(files)
+	for _, f := range files {
+		findings := engine.Grade(duplicates[f.Path])
+		if len(findings) == 0 {
+			continue
+		}
+		i := slices.IndexFunc(results, func(r output.Report) bool { return r.Path == f.Path })
+		if i < 0 {
+			continue
+		}
+		report := &results[i]
+		ignores, _ := input.LoadIgnores(filepath.Dir(f.Path)) // 読み込みエラーは processFile で報告済み
+		findings, suppressed := ignores.Apply(findings, f.Path, f.Sheet.ProjectID, time.Now())
+		for _, s := range suppressed {
+			report.Suppressed = append(report.Suppressed, s.String())
+		}
+		for _, finding := range findings {
+			report.Findings = append(report.Findings, localFinding(finding))
+		}
+		report.StatusCode = max(report.StatusCode, report.WorstStatus())
 	}
+}
 
-	var locationParts []string
-	if e.Index != nil {
-		locationParts = append(locationParts, fmt.Sprintf("%d行目", *e.Index+1))
+// applyHints はカタログから指摘ごとの対処方法を探してレポートに設定します。
+// ローカル検証はルールID、PNSearchの指摘はメッセージとキーで探します。
+func applyHints(results []output.Report, hints *hint.Catalog) {
+	for i := range results {
+		for j := range results[i].Findings {
+			f := &results[i].Findings[j]
+			var (
+				h  hint.Hint
+				ok bool
+			)
+			switch f.Source {
+			case output.SourceLocal:
+				h, ok = hints.Rule(f.ID)
+			case output.SourceServer, output.SourceOverride:
+				h, ok = hints.Server(f.Message, f.Field)
+			}
+			if ok {
+				f.Hint, f.HelpURL = h.Text, h.URL
+			}
+		}
 	}
-	if e.Key != "" {
-		locationParts = append(locationParts, e.Key)
+}
+
+// localFinding はローカル検証ルールのFindingをレポート用に変換します。
+func localFinding(f input.Finding) output.Finding {
+	return output.Finding{
+		Source:   output.SourceLocal,
+		ID:       f.RuleID,
+		Severity: f.Severity.String(),
+		Sheet:    f.Sheet,
+		Row:      f.Row,
+		Column:   f.Column(),
+		Cell:     f.Cell,
+		Message:  f.Message,
 	}
+}
 
-	if len(locationParts) > 0 {
-		parts = append(parts, fmt.Sprintf("[%s]", strings.Join(locationParts, ":")))
+// serverFinding はPNSearchが返したErrorRecordをレポート用に変換します。
+// 重大度は応答のステータスコードから決め、明細の番号は入力Ⅰの行番号に直します。
+func serverFinding(e api.ErrorRecord, code output.StatusCode, sheet *input.Sheet) output.Finding {
+	f := output.Finding{
+		Source:   output.SourceServer,
+		ID:       e.Message,
+		Severity: code.Severity(),
+		Field:    e.Key,
+		Message:  e.Message,
+		Details:  e.Details,
 	}
+	if e.Index != nil {
+		if name, row, ok := sheet.OrderLocation(*e.Index); ok {
+			f.Sheet, f.Row = name, row
+		} else {
+			f.Index = *e.Index + 1 // 対応する行がなければ明細の番号を示す
+		}
+	}
+	return f
+}
 
-	if len(parts) > 0 {
-		return fmt.Sprintf("%s: %s", e.Message, strings.Join(parts, " "))
+// serverFindings はPNSearchの応答のメッセージとErrorRecordをレポート用に変換します。
+// withMessage がfalseなら応答のメッセージは含めません。
+func serverFindings(resp *api.APIResponse, code output.StatusCode, sheet *input.Sheet, withMessage bool) (findings []output.Finding) {
+	if withMessage && resp.Message != "" {
+		findings = append(findings, output.Finding{
+			Source:   output.SourceServer,
+			Severity: code.Severity(),
+			Message:  resp.Message,
+		})
 	}
-	return e.Message
+	for _, e := range resp.PNResponse.Error {
+		findings = append(findings, serverFinding(e, code, sheet))
+	}
+	return
 }
 
-// handleOverridePost はエラー時のオーバーライドPOST処理を実行し、
-// reportを完全に更新します
-func handleOverridePost(report *output.Report, sheet *input.Sheet) error {
-	sheet.Config.Validatable = false // あえてワーニングを表示するためエラーチェック無効化
-	sheet.Config.Overridable = true  // サーバー側の自動更新を許可
-	body, code, err := sheet.Post()
-	if err != nil {
-		return fmt.Errorf("API通信エラー(2回目): %v", err)
+// systemFinding はExcelの読み込みやAPI通信などのエラーをレポート用に変換します。
+func systemFinding(severity, format string, a ...any) output.Finding {
+	return output.Finding{
+		Source:   output.SourceSystem,
+		Severity: severity,
+		Message:  i18n.T(format, a...),
 	}
+}
 
-	resp, err := api.JSONParse(body)
+// cellErrorFinding はセルの読み込みエラーを、セル番地を含めてレポート用に変換します。
+func cellErrorFinding(e *input.CellError) output.Finding {
+	f := systemFinding(output.SeverityFatal, "Excel読み込みエラー: %v", e)
+	f.Sheet, f.Row, f.Column, f.Cell, f.Field = e.Sheet, e.Row, e.Column, e.Cell(), e.Field
+	return f
+}
+
+// writeFixedFile は要求票の修正版を作成し、保存先と修正内容をreportに設定します。
+//
+// fixがtrueなら、正規化した値の書き込みと隠し列の削除を元の行番号のまま行ってから、明細を並べ替えます。
+// sortOrdersがfalseなら、明細を並べ替えません。
+// serverがnilでなければ、PNSearchが修正した値を書き込みます。
+// fixがfalseでPNSearchの修正もなければ、修正版を作成しません。
+func writeFixedFile(report *output.Report, filePath string, sheet *input.Sheet, changes []input.Change, fix, sortOrders bool, server *input.Sheet) error {
+	fx, err := input.OpenFixer(filePath)
 	if err != nil {
-		return fmt.Errorf("APIレスポンス解析エラー(2回目): %v", err)
+		return err
 	}
+	defer fx.Close()
 
-	// reportを完全に更新
-	report.Link = input.BuildRequestURL(resp.PNResponse.SHA256)
-	report.StatusCode = output.StatusCode(code)
+	if fix {
+		if err := fx.Normalize(changes); err != nil {
+			return err
+		}
+		if err := fx.ClearHiddenColumns(); err != nil {
+			return err
+		}
+		if sortOrders {
+			if err := fx.SortOrders(sheet); err != nil {
+				return err
+			}
+		}
+	}
+	if server != nil {
+		if err := fx.ApplyServerSheet(sheet, server); err != nil {
+			return err
+		}
+	}
+	if !fix && len(fx.Log) == 0 {
+		return nil
+	}
+	if err := fx.Save(); err != nil {
+		return err
+	}
+	report.FixedFile = fx.Path
+	report.Fixes = fx.Log
+	return nil
+}
 
-	// エラーメッセージを直接設定
-	var errs []string
-	if resp.Message != "" {
-		errs = append(errs, resp.Message)
+// sheetDiffs はpncheckが読み込んだ要求票とPNSearchが返した要求票の差分をレポート表示用に並べます。
+// PNSearchが明細を返さなかった場合は比較しません。
+func sheetDiffs(local, server *input.Sheet) (diffs []output.Diff) {
+	if len(server.Orders) == 0 {
+		return
 	}
-	for _, e := range resp.PNResponse.Error {
-		errs = append(errs, formatErrorMessage(e))
+	for _, d := range input.DiffSheets(local, server) {
+		diffs = append(diffs, output.Diff{Location: d.Location(), Field: d.Field, Local: d.Local, Server: d.Server})
 	}
-	report.ErrorMessages = errs
+	return
+}
 
-	return nil
+// filenameProperties はファイル名から読み取った項目をレポート表示用に並べます。
+// resolvedは検証に使った発注区分で、ファイル名の発注区分と異なれば両方を表示します。
+// 要求票を読み込めていなければ空文字を渡します。
+func filenameProperties(fn input.Filename, resolved input.OrderType) []output.Property {
+	date := fn.DateText
+	if !fn.Date.IsZero() {
+		date = fn.Date.Format(input.DateLayout)
+	}
+	orderType := string(fn.OrderType())
+	if fn.OrderCode != "" {
+		orderType = fmt.Sprintf("%s (%s)", orderType, fn.OrderCode)
+	}
+	if resolved != "" && resolved != fn.OrderType() {
+		orderType = i18n.T("%s (内容から推定。ファイル名: %s)", resolved, orderType)
+	}
+	return []output.Property{
+		{Name: i18n.T("日付"), Value: date},
+		{Name: i18n.T("製番"), Value: fn.Project},
+		{Name: i18n.T("号機"), Value: fn.Serial},
+		{Name: i18n.T("発注区分"), Value: orderType},
+		{Name: i18n.T("補足"), Value: fn.Suffix},
+	}
 }
 
-func processFile(filePath string, resultChan chan<- output.Report, debugLevel int) {
-	var report output.Report
-	report.Filename = filepath.Base(filePath)
+// fileState : processFile の処理の段階
+//
+// 各段階の処理は次の段階を返す。処理を続けられないエラーが起きた段階は stateDone を返し、
+// それまでの指摘を1つのレポートにまとめて終える。
+type fileState int
+
+const (
+	stateOpen     fileState = iota // Excelファイルを開く
+	stateRead                      // 要求票を読み込み、文字列を正規化する
+	stateActivate                  // 元のファイルの入力Iをアクティブにする
+	stateValidate                  // 1回目のPOSTとローカル検証
+	stateOverride                  // 2回目のPOST。PNSearchに品名、型式、単位を修正させる
+	stateFinish                    // PNSearchとの差分の表示と修正版の作成
+	stateDone                      // 終了
+)
+
+// fileJob : 1つのExcelファイルの処理状態
+//
+// 1回目のPOST(検証)と2回目のPOST(自動修正)の結果を1つのレポートにまとめる。
+type fileJob struct {
+	path       string
+	debugLevel int
+	cfg        *config.Config
+	engine     *input.Engine
+	fix        bool
 
-	// Excelファイルの読み込み
-	sheet, err := input.ReadExcelToSheet(filePath)
+	report    output.Report
+	wb        *input.Workbook
+	sheet     input.Sheet
+	parsed    bool              // 要求票を読み込めたか (一部のセルを読み込めなかった場合を含む)
+	parseErrs input.ParseErrors // 読み込めなかったセル。あればPOSTせずにローカル検証だけ行う
+	changes   []input.Change    // 正規化で書き換えた値
+	resp      *api.APIResponse  // 1回目のPOSTの応答
+	code      int               // 1回目のPOSTのステータスコード
+	server    *input.Sheet      // 2回目のPOSTでPNSearchが修正して返したSheet
+}
+
+// step : stateの処理を実行し、次の段階を返す
+func (j *fileJob) step(state fileState) fileState {
+	switch state {
+	case stateOpen:
+		return j.open()
+	case stateRead:
+		return j.read()
+	case stateActivate:
+		return j.activate()
+	case stateValidate:
+		return j.validate()
+	case stateOverride:
+		return j.override()
+	case stateFinish:
+		return j.finish()
+	}
+	return stateDone
+}
+
+// fail : 処理を続けられないエラーをFatalの指摘として記録し、処理を終える
+func (j *fileJob) fail(format string, a ...any) fileState {
+	j.report.Findings = append(j.report.Findings, systemFinding(output.SeverityFatal, format, a...))
+	return stateDone
+}
+
+// open : Excelファイルを1回だけ開いて、読み込みとローカル検証で共有する
+func (j *fileJob) open() fileState {
+	wb, err := input.OpenWorkbook(j.path)
 	if err != nil {
-		report.StatusCode = 500
-		report.ErrorMessages = append(report.ErrorMessages, fmt.Sprintf("Excel読み込みエラー: %v", err))
-		resultChan <- report
-		return
+		return j.fail("Excel読み込みエラー: %v", err)
+	}
+	j.wb = wb
+	return stateRead
+}
+
+// read : 要求票を読み込み、PNSearchで照合できるよう文字列を正規化して書き換えた値をレポートに残す
+func (j *fileJob) read() fileState {
+	sheet, err := j.wb.ReadSheet()
+	if err != nil {
+		// 明細行のパースエラーはすべての行を1つのレポートにまとめて表示し、
+		// 読み込めた範囲のSheetで検証を続ける
+		if !errors.As(err, &j.parseErrs) || len(j.parseErrs) == 0 {
+			return j.fail("Excel読み込みエラー: %v", err)
+		}
+		for _, e := range j.parseErrs {
+			j.report.Findings = append(j.report.Findings, cellErrorFinding(e))
+		}
+	}
+	j.sheet = sheet
+	j.parsed = true
+	j.report.FileProperties = filenameProperties(input.ParseFilename(j.path), j.sheet.OrderType)
+
+	j.changes = input.Normalize(&j.sheet, j.cfg.Normalize)
+	j.changes = append(j.changes, input.NormalizeUnits(&j.sheet, j.cfg.Units)...)
+	for _, c := range j.changes {
+		j.report.Changes = append(j.report.Changes, c.String())
 	}
 
 	// Debug Print: Excel parse, API request
-	if debugLevel > 2 {
-		jsonData, err := json.MarshalIndent(sheet, "", "  ")
+	if j.debugLevel > 2 {
+		jsonData, err := json.MarshalIndent(j.sheet, "", "  ")
 		if err != nil {
-			err = fmt.Errorf("Sheet構造体のJSON変換に失敗しました: %w", err)
-			return
+			return j.fail("Sheet構造体のJSON変換に失敗しました: %v", err)
 		}
 		fmt.Printf("%s\n", jsonData)
 	}
+	return stateActivate
+}
+
+// activate : 元のファイルの入力Iをアクティブにする
+// 修正版を作る場合は元のファイルを変更せず、修正版で入力Iをアクティブにする
+func (j *fileJob) activate() fileState {
+	if !j.fix {
+		if err := j.wb.ActivateOrderSheet(); err != nil {
+			return j.fail("入力Iのアクティベーションエラー: %v", err)
+		}
+	}
+	return stateValidate
+}
 
-	if err := input.ActivateOrderSheet(filePath); err != nil {
-		report.StatusCode = 500
-		report.ErrorMessages = append(report.ErrorMessages, fmt.Sprintf("入力Iのアクティベーションエラー: %v", err))
-		resultChan <- report
-		return
+// validate : 1回目のPOSTでPNSearchの検証を受け、ローカルのルールで検証する
+// 読み込めないセルがあれば、誤った値をPNSearchへ送らないようローカル検証と修正版の作成だけで終える
+func (j *fileJob) validate() fileState {
+	if len(j.parseErrs) > 0 {
+		j.validateLocal()
+		j.writeFixed()
+		return stateDone
 	}
 
-	// 2. 1回目のPOST
-	sheet.Config.Validatable = true  // エラーチェック有効化
-	sheet.Config.Overridable = false // サーバー側の自動更新を無効化
-	body, code, err := sheet.Post()
+	j.sheet.Config.Validatable = true  // エラーチェック有効化
+	j.sheet.Config.Overridable = false // サーバー側の自動更新を無効化
+	body, code, err := j.sheet.Post()
 	if err != nil {
-		report.StatusCode = 500 // API通信自体が失敗した場合はFatal
-		report.ErrorMessages = append(report.ErrorMessages, fmt.Sprintf("API通信エラー: %v", err))
-		resultChan <- report
-		return
+		return j.fail("API通信エラー: %v", err)
 	}
 
 	// Debug Print API response
-	if debugLevel > 1 {
+	if j.debugLevel > 1 {
 		fmt.Printf("%s\n", body)
 	}
 
 	resp, err := api.JSONParse(body)
 	if err != nil {
-		report.StatusCode = 500 // レスポンス解析エラーもFatal
-		report.ErrorMessages = append(report.ErrorMessages, fmt.Sprintf("APIレスポンス解析エラー: %v", err))
-		resultChan <- report
-		return
+		return j.fail("APIレスポンス解析エラー: %v", err)
 	}
+	j.resp, j.code = resp, code
+	j.validateLocal()
 
-	// 3. ローカルのエラー収集
-	errs := input.CollectLocalErrors(&sheet, filePath)
-	if errs != nil {
-		report.StatusCode = 500
-	} else {
-		report.StatusCode = output.StatusCode(code)
+	// APIからのエラー収集
+	j.report.Findings = append(j.report.Findings, serverFindings(resp, output.StatusCode(code), &j.sheet, code >= 400)...)
+	j.report.Link = input.BuildRequestURL(resp.PNResponse.SHA256)
+
+	// 400番台: 2回目のPOSTでPNSearchが修正した値を受け取る
+	if code >= 400 && code < 500 {
+		return stateOverride
 	}
+	return stateFinish
+}
 
-	// 4. APIからのエラー収集
-	if resp.Message != "" && code >= 400 {
-		errs = append(errs, resp.Message)
+// validateLocal : ローカルのルールで検証する
+// 抑制設定に一致した指摘はステータスに含めず、レポートの別枠に表示する
+func (j *fileJob) validateLocal() {
+	findings := slices.DeleteFunc(j.engine.Run(j.wb, &j.sheet), j.parseErrs.Covers)
+	ignores, ignoreErr := input.LoadIgnores(filepath.Dir(j.path))
+	findings, suppressed := ignores.Apply(findings, j.path, j.sheet.ProjectID, time.Now())
+	for _, s := range suppressed {
+		j.report.Suppressed = append(j.report.Suppressed, s.String())
 	}
-	for _, e := range resp.PNResponse.Error {
-		errs = append(errs, formatErrorMessage(e))
+	input.SortFindings(findings)
+	// 抑制設定ファイルが不正なら抑制せずに検証し、Warningとして知らせる
+	if ignoreErr != nil {
+		j.report.Findings = append(j.report.Findings, systemFinding(output.SeverityWarning, "%v", ignoreErr))
+	}
+	// 参考情報(Info)はステータスに影響せず、レポートでは問題と分けて表示する
+	for _, f := range findings {
+		j.report.Findings = append(j.report.Findings, localFinding(f))
+	}
+}
+
+// override : 2回目のPOSTでPNSearchに品名、型式、単位を修正させる
+// 応答の指摘は自動修正の結果として、1回目の指摘と同じレポートに加える
+func (j *fileJob) override() fileState {
+	j.sheet.Config.Validatable = false // あえてワーニングを表示するためエラーチェック無効化
+	j.sheet.Config.Overridable = true  // サーバー側の自動更新を許可
+	body, code, err := j.sheet.Post()
+	if err != nil {
+		return j.fail("API通信エラー(2回目): %v", err)
 	}
-	report.Link = input.BuildRequestURL(resp.PNResponse.SHA256)
-	report.ErrorMessages = errs
 
-	// 1回目のレポート送信
-	// 300番台以下：Warning/Success - そのまま返す
-	resultChan <- report
+	resp, err := api.JSONParse(body)
+	if err != nil {
+		return j.fail("APIレスポンス解析エラー(2回目): %v", err)
+	}
 
-	// 400番台: Error処理で1回目のレポートを送信後、2回目のPOSTを実行
-	if code >= 400 && code < 500 {
-		secondReport := output.Report{
-			Filename: filepath.Base(filePath),
-		}
-		// 2回目のPOST (オーバーライド)
-		if err := handleOverridePost(&secondReport, &sheet); err != nil {
-			// システムエラーの場合
-			secondReport.StatusCode = 500
-			secondReport.ErrorMessages = []string{err.Error()}
-		}
-		// 2回目POSTのSuccess(200-299)は偽物(1回目で同ファイル名のErrorを送信済み)なので除外
-		// Warning(300-399) / Error(400-499) / Fatal(500-) は正当な結果として送信
-		if secondReport.StatusCode >= 300 {
-			resultChan <- secondReport
-		}
+	j.report.OverrideLink = input.BuildRequestURL(resp.PNResponse.SHA256)
+	for _, f := range serverFindings(resp, output.StatusCode(code), &j.sheet, true) {
+		f.Source = output.SourceOverride
+		j.report.Findings = append(j.report.Findings, f)
+	}
+	j.server = &resp.PNResponse.Sheet
+	return stateFinish
+}
+
+// finish : PNSearchが最後に返した要求票との差分を表示し、修正版を作成する
+func (j *fileJob) finish() fileState {
+	latest := &j.resp.PNResponse.Sheet
+	if j.server != nil {
+		latest = j.server
+	}
+	j.report.Diffs = sheetDiffs(&j.sheet, latest)
+	j.writeFixed()
+	return stateDone
+}
+
+// writeFixed : -fix の修正とPNSearchが修正した値を修正版に書き込む
+// 修正版の作成に失敗しても検証結果は表示し、Warningとして知らせる
+// 読み込めないセルがあれば、正しい並び順がわからないので明細を並べ替えない
+func (j *fileJob) writeFixed() {
+	if !j.fix && j.server == nil {
+		return
+	}
+	partial := len(j.parseErrs) > 0
+	if err := writeFixedFile(&j.report, j.path, &j.sheet, j.changes, j.fix, !partial, j.server); err != nil {
+		j.report.Findings = append(j.report.Findings, systemFinding(output.SeverityWarning, "修正版の作成エラー: %v", err))
+		return
+	}
+	if j.fix && partial {
+		j.report.Findings = append(j.report.Findings, systemFinding(output.SeverityWarning, "読み込めないセルがあるため、修正版の明細を並べ替えていません"))
+	}
+}
+
+// processFile は1つのExcelファイルを検証し、1回目と2回目のPOSTの結果をまとめた1つのレポートを返します。
+// 要求票を読み込めた場合は、ファイルをまたぐ確認のためにSheetも返します。
+// fixがtrueなら修正版を作成し、元のファイルには入力Iのアクティベーションも保存しません。
+//
+// ステータスは1回目のPOSTのステータスコードと、最も重い指摘の重大度のうち重い方です。
+func processFile(filePath string, debugLevel int, cfg *config.Config, engine *input.Engine, fix bool) (output.Report, *input.Sheet) {
+	j := &fileJob{
+		path:       filePath,
+		debugLevel: debugLevel,
+		cfg:        cfg,
+		engine:     engine,
+		fix:        fix,
+	}
+	j.report.Filename = filepath.Base(filePath)
+	j.report.Path = filePath
+	j.report.FileProperties = filenameProperties(input.ParseFilename(filePath), "")
+
+	for state := stateOpen; state != stateDone; {
+		state = j.step(state)
+	}
+	if j.wb != nil {
+		j.wb.Close()
+	}
+
+	j.report.StatusCode = max(output.StatusCode(j.code), j.report.WorstStatus())
+	if !j.parsed {
+		return j.report, nil
 	}
+	return j.report, &j.sheet
 }
@@ -1 +1,237 @@
 package lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/api"
+	"pncheck/lib/config"
+	"pncheck/lib/hint"
+	"pncheck/lib/i18n"
+	"pncheck/lib/input"
+	"pncheck/lib/output"
+)
+
+func TestApplyCrossFileFindings(t *testing.T) {
+	engine, err := input.NewEngine(config.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet := func(pid string) *input.Sheet {
+		return &input.Sheet{
+			Header: input.Header{ProjectID: "123456789000"},
+			Orders: input.Orders{{Pid: pid, Quantity: 1, Row: 2}},
+		}
+	}
+	// 別のディレクトリにある同名のファイルは別のファイルとして扱う
+	files := []input.FileSheet{
+		{Path: "b.xlsx", Sheet: sheet("A")},
+		{Path: "a.xlsx", Sheet: sheet("A")},
+		{Path: "c.xlsx", Sheet: sheet("B")},
+		{Path: filepath.Join("p1", "d.xlsx"), Sheet: sheet("C")},
+		{Path: filepath.Join("p2", "d.xlsx"), Sheet: sheet("C")},
+	}
+	results := []output.Report{
+		{Filename: "a.xlsx", Path: "a.xlsx", StatusCode: 200},
+		{Filename: "b.xlsx", Path: "b.xlsx", StatusCode: 400},
+		{Filename: "c.xlsx", Path: "c.xlsx", StatusCode: 200},
+		{Filename: "d.xlsx", Path: filepath.Join("p1", "d.xlsx"), StatusCode: 200},
+		{Filename: "d.xlsx", Path: filepath.Join("p2", "d.xlsx"), StatusCode: 200},
+	}
+
+	applyCrossFileFindings(results, files, engine)
+
+	wants := []struct {
+		messages int
+		status   output.StatusCode
+	}{{1, 300}, {1, 400}, {0, 200}, {1, 300}, {1, 300}}
+	for i, want := range wants {
+		if got := len(results[i].Findings); got != want.messages {
+			t.Errorf("results[%d].Findings = %v, want %d findings", i, results[i].Findings, want.messages)
+		}
+		if results[i].StatusCode != want.status {
+			t.Errorf("results[%d].StatusCode = %d, want %d", i, results[i].StatusCode, want.status)
+		}
+	}
+}
+
+func TestProcessFile_OpenError(t *testing.T) {
+	engine, err := input.NewEngine(config.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, sheet := processFile(filepath.Join(t.TempDir(), "missing.xlsx"), 0, config.Default(), engine, false)
+	if sheet != nil {
+		t.Errorf("processFile() sheet = %v, want nil", sheet)
+	}
+	if report.Filename != "missing.xlsx" || report.StatusCode != 500 {
+		t.Errorf("processFile() = %q %d, want %q 500", report.Filename, report.StatusCode, "missing.xlsx")
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Source != output.SourceSystem {
+		t.Errorf("processFile() Findings = %+v, want 1 system finding", report.Findings)
+	}
+}
+
+func TestProcessFile_PartialSheet(t *testing.T) {
+	engine, err := input.NewEngine(config.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := excelize.NewFile()
+	for _, name := range []string{"入力Ⅱ", "入力Ⅰ", "10品目用"} {
+		_, _ = f.NewSheet(name)
+	}
+	_ = f.DeleteSheet("Sheet1")
+	f.SetCellValue("入力Ⅱ", "D1", "123456789000")
+	f.SetCellValue("入力Ⅱ", "D2", "2023/11/30")
+	f.SetCellValue("入力Ⅱ", "D4", "2023/10/27")
+	f.SetCellValue("10品目用", "AV1", "M-701-04")
+	f.SetCellValue("入力Ⅰ", "E2", "PN-1")
+	f.SetCellValue("入力Ⅰ", "I2", "abc") // 数値でない数量
+	f.SetCellValue("入力Ⅰ", "E3", "PN-2")
+	f.SetCellValue("入力Ⅰ", "I3", "1")
+	f.SetCellValue("入力Ⅰ", "J3", "2023/09/01") // 要求年月日より前の要望納期
+	path := filepath.Join(t.TempDir(), "20231027-123456789000-TBD-K.xlsx")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+
+	report, sheet := processFile(path, 0, config.Default(), engine, false)
+	if sheet == nil || len(sheet.Orders) != 2 {
+		t.Fatalf("processFile() sheet = %+v, want a partial sheet with 2 orders", sheet)
+	}
+	if report.StatusCode != 500 {
+		t.Errorf("processFile() StatusCode = %d, want 500", report.StatusCode)
+	}
+	var system, local int
+	for _, f := range report.Findings {
+		switch f.Source {
+		case output.SourceSystem:
+			system++
+			if f.Cell != "I2" {
+				t.Errorf("読み込みエラーのセル = %q, want I2", f.Cell)
+			}
+		case output.SourceLocal:
+			local++
+			// 読み込めなかったセルはゼロ値のまま検証するので、同じセルへの指摘は除く
+			if f.Cell == "I2" {
+				t.Errorf("読み込めなかったセルへの指摘があります: %+v", f)
+			}
+		default:
+			t.Errorf("PNSearchへPOSTしています: %+v", f)
+		}
+	}
+	if system != 1 || local == 0 {
+		t.Errorf("processFile() Findings = %+v, want 1 system finding and local findings", report.Findings)
+	}
+
+	// -fix では並べ替えずに修正版を作成し、そのことを知らせる
+	report, _ = processFile(path, 0, config.Default(), engine, true)
+	if _, err := os.Stat(report.FixedFile); report.FixedFile == "" || err != nil {
+		t.Errorf("processFile(fix) FixedFile = %q (%v), want a fixed file", report.FixedFile, err)
+	}
+	var warned bool
+	for _, f := range report.Findings {
+		warned = warned || (f.Source == output.SourceSystem && f.Severity == output.SeverityWarning)
+	}
+	if !warned {
+		t.Errorf("processFile(fix) Findings = %+v, want a warning that the lines were not sorted", report.Findings)
+	}
+}
+
+func TestServerFinding(t *testing.T) {
+	sheet := &input.Sheet{Orders: input.Orders{{Pid: "A", Row: 2}, {Pid: "B", Row: 5}}}
+	index := func(i int) *int { return &i }
+	tests := []struct {
+		name      string
+		record    api.ErrorRecord
+		wantSheet string
+		wantRow   int
+		wantIndex int
+	}{
+		{"明細の番号を入力Ⅰの行番号に直す", api.ErrorRecord{Message: "m", Key: "Pid", Index: index(1)}, "入力Ⅰ", 5, 0},
+		{"範囲外なら行番号でなく明細の番号", api.ErrorRecord{Message: "m", Index: index(2)}, "", 0, 3},
+		{"番号がなければ行なし", api.ErrorRecord{Message: "m"}, "", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serverFinding(tt.record, 400, sheet)
+			if got.Sheet != tt.wantSheet || got.Row != tt.wantRow || got.Index != tt.wantIndex {
+				t.Errorf("serverFinding() = %q %d %d, want %q %d %d",
+					got.Sheet, got.Row, got.Index, tt.wantSheet, tt.wantRow, tt.wantIndex)
+			}
+			if got.Source != output.SourceServer || got.Severity != output.SeverityError || got.Field != tt.record.Key {
+				t.Errorf("serverFinding() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestApplyHints(t *testing.T) {
+	hints := &hint.Catalog{
+		Rules: map[string]hint.Hint{"sort-order": {Text: "並べ替えてください", URL: "http://wiki/sort"}},
+		Keys:  map[string]hint.Hint{"品番": {Text: "品番を確認してください"}},
+	}
+	results := []output.Report{{Findings: []output.Finding{
+		{Source: output.SourceLocal, ID: "sort-order"},
+		{Source: output.SourceOverride, Message: "品番が見つかりません", Field: "品番"},
+		{Source: output.SourceSystem, Message: "sort-order"},
+		{Source: output.SourceLocal, ID: "unit"},
+	}}}
+
+	applyHints(results, hints)
+
+	wants := []struct{ hint, url string }{
+		{"並べ替えてください", "http://wiki/sort"},
+		{"品番を確認してください", ""},
+		{"", ""},
+		{"", ""},
+	}
+	for i, want := range wants {
+		if f := results[0].Findings[i]; f.Hint != want.hint || f.HelpURL != want.url {
+			t.Errorf("Findings[%d] = %q %q, want %q %q", i, f.Hint, f.HelpURL, want.hint, want.url)
+		}
+	}
+}
+
+func TestDefaultHintsCoverRules(t *testing.T) {
+	engine, err := input.NewEngine(config.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer i18n.SetLang(i18n.Current())
+	for _, lang := range []i18n.Lang{i18n.Ja, i18n.En} {
+		i18n.SetLang(lang)
+		hints := hint.Default()
+		for _, r := range engine.Rules() {
+			if _, ok := hints.Rule(r.ID()); !ok {
+				t.Errorf("ルール %s の既定のヒント(%s)がありません", r.ID(), lang)
+			}
+		}
+	}
+}
+
+func TestFilenameProperties(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		resolved input.OrderType
+		want     string
+	}{
+		{"ファイル名どおり", "20231027-123456789000-001-K.xlsx", input.OrderType("購入"), "購入 (K)"},
+		{"内容から推定", "20231027-123456789000-001-X.xlsx", input.OrderType("購入"), "購入 (内容から推定。ファイル名: 組部品 (X))"},
+		{"読み込み前", "20231027-123456789000-001-X.xlsx", "", "組部品 (X)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, p := range filenameProperties(input.ParseFilename(tt.path), tt.resolved) {
+				if p.Name == "発注区分" && p.Value != tt.want {
+					t.Errorf("発注区分 = %q, want %q", p.Value, tt.want)
+				}
+			}
+		})
+	}
+}
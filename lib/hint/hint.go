@@ -0,0 +1,105 @@
+/*
+hint パッケージでは、
+指摘ごとの対処方法(ヒント)のカタログを扱います。
+
+既定のカタログは日本語(hints.json)と英語(hints.en.json)が実行ファイルに埋め込まれており、
+表示する言語のものを使います。
+設定ファイルの hints.file で指定したファイルの内容で項目ごとに上書きできます。
+*/
+package hint
+
+import (
+	_ "embed" // 既定のカタログを埋め込む
+	"encoding/json"
+	"os"
+
+	"pncheck/lib/i18n"
+)
+
+var (
+	//go:embed hints.json
+	defaultCatalog []byte
+	//go:embed hints.en.json
+	defaultCatalogEn []byte
+)
+
+// Hint : 1件の指摘に対する対処方法
+type Hint struct {
+	Text string `json:"text"`          // 対処方法
+	URL  string `json:"url,omitempty"` // 詳しい説明のURL
+}
+
+// Catalog : 指摘とヒントの対応
+type Catalog struct {
+	Rules    map[string]Hint `json:"rules"`    // ローカル検証のルールID (例: sort-order)
+	Messages map[string]Hint `json:"messages"` // PNSearchのErrorRecordのメッセージ
+	Keys     map[string]Hint `json:"keys"`     // PNSearchのErrorRecordのキー (例: 品番)
+}
+
+// Default は埋め込まれた既定のカタログのうち、表示する言語のものを返します。
+func Default() *Catalog {
+	b := defaultCatalog
+	if i18n.Current() == i18n.En {
+		b = defaultCatalogEn
+	}
+	c, err := parse(b)
+	if err != nil {
+		panic(i18n.T("既定のヒントが不正です: %v", err)) // ビルド時に埋め込むファイルの誤り
+	}
+	return c
+}
+
+// Load は既定のカタログにpathのファイルの内容を上書きして返します。
+// pathが空文字なら既定のカタログを返します。
+//
+// ファイルの項目は既定の同じ項目を置き換え、text と url が空の項目は既定のヒントを削除します。
+func Load(path string) (*Catalog, error) {
+	c := Default()
+	if path == "" {
+		return c, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, i18n.Errorf("ヒントファイルを読み込めません '%s': %w", path, err)
+	}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, i18n.Errorf("ヒントファイルのJSON解析に失敗しました '%s': %w", path, err)
+	}
+	for _, m := range []map[string]Hint{c.Rules, c.Messages, c.Keys} {
+		for k, h := range m {
+			if h == (Hint{}) {
+				delete(m, k)
+			}
+		}
+	}
+	return c, nil
+}
+
+// parse : JSONからカタログを作る
+func parse(b []byte) (*Catalog, error) {
+	c := &Catalog{
+		Rules:    make(map[string]Hint),
+		Messages: make(map[string]Hint),
+		Keys:     make(map[string]Hint),
+	}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Rule はローカル検証のルールIDに対応するヒントを返します。
+func (c *Catalog) Rule(id string) (Hint, bool) {
+	h, ok := c.Rules[id]
+	return h, ok
+}
+
+// Server はPNSearchの指摘に対応するヒントを返します。
+// メッセージに一致するヒントがなければ、キー(項目名)のヒントを返します。
+func (c *Catalog) Server(message, key string) (Hint, bool) {
+	if h, ok := c.Messages[message]; ok {
+		return h, true
+	}
+	h, ok := c.Keys[key]
+	return h, ok
+}
@@ -0,0 +1,79 @@
+package hint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	c := Default()
+	if h, ok := c.Rule("sort-order"); !ok || h.Text == "" {
+		t.Errorf("Rule(sort-order) = %+v, %v, want a hint", h, ok)
+	}
+	if _, ok := c.Rule("no-such-rule"); ok {
+		t.Error("Rule(no-such-rule) にヒントがあります")
+	}
+}
+
+func TestCatalog_Server(t *testing.T) {
+	c := &Catalog{
+		Messages: map[string]Hint{"品番が見つかりません": {Text: "品番を検索してください"}},
+		Keys:     map[string]Hint{"品番": {Text: "品番を確認してください"}, "品名": {Text: "品名を確認してください"}},
+	}
+	tests := []struct {
+		name, message, key string
+		want               string
+		ok                 bool
+	}{
+		{"メッセージが優先", "品番が見つかりません", "品番", "品番を検索してください", true},
+		{"メッセージがなければキー", "品名が違います", "品名", "品名を確認してください", true},
+		{"どちらもなし", "不明なエラー", "数量", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, ok := c.Server(tt.message, tt.key)
+			if ok != tt.ok || h.Text != tt.want {
+				t.Errorf("Server(%q, %q) = %+v, %v, want %q, %v", tt.message, tt.key, h, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hints.json")
+	content := `{
+		"rules": {
+			"sort-order": {"text": "並べ替えてください", "url": "http://wiki/sort"},
+			"unit": {"text": ""},
+			"dept-qty": {"text": "部署の独自ルールです"}
+		},
+		"messages": {"品番が見つかりません": {"text": "品番を検索してください"}}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if h, _ := c.Rule("sort-order"); h != (Hint{Text: "並べ替えてください", URL: "http://wiki/sort"}) {
+		t.Errorf("Rule(sort-order) = %+v, 上書きされていません", h)
+	}
+	if _, ok := c.Rule("unit"); ok {
+		t.Error("Rule(unit): 空のヒントで既定のヒントが削除されていません")
+	}
+	if _, ok := c.Rule("dept-qty"); !ok {
+		t.Error("Rule(dept-qty): 独自ルールのヒントが追加されていません")
+	}
+	if _, ok := c.Rule("hidden-column"); !ok {
+		t.Error("Rule(hidden-column): ファイルにない既定のヒントが残っていません")
+	}
+	if h, ok := c.Server("品番が見つかりません", ""); !ok || h.Text != "品番を検索してください" {
+		t.Errorf("Server() = %+v, %v", h, ok)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("存在しないファイルでエラーが返されませんでした")
+	}
+}
@@ -0,0 +1,107 @@
+/*
+i18n パッケージでは、
+利用者に表示するメッセージの言語(日本語・英語)の切り替えを扱います。
+
+メッセージはソースコードに日本語の書式文字列として書き、その文字列をキーとして
+各言語のカタログ(en.json)から翻訳を探します。日本語はソースコードの文字列をそのまま使い、
+カタログに翻訳がないメッセージも日本語で表示します。
+
+利用者に表示するメッセージは T または Errorf に文字列リテラルで渡します。
+go test ./lib/i18n は、en.json に翻訳がないメッセージと、fmt.Errorf や slog などに
+日本語を直接渡して翻訳されないメッセージを報告します。
+*/
+package i18n
+
+import (
+	_ "embed" // 英語のカタログを埋め込む
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Lang : 表示する言語
+type Lang string
+
+// 対応している言語
+const (
+	Ja Lang = "ja" // 日本語 (既定)
+	En Lang = "en" // 英語
+)
+
+//go:embed en.json
+var enCatalog []byte
+
+var (
+	// current : 表示する言語。起動時に SetLang で1回だけ設定する
+	current = Ja
+	// catalogs : 言語ごとの 日本語の書式文字列 → 翻訳 の対応
+	catalogs = map[Lang]map[string]string{
+		En: mustParse(enCatalog),
+	}
+)
+
+// mustParse : 埋め込んだカタログを読み込む
+func mustParse(b []byte) map[string]string {
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		panic(fmt.Sprintf("メッセージカタログが不正です: %v", err)) // ビルド時に埋め込むファイルの誤り
+	}
+	return m
+}
+
+// Parse は ja または en を言語に変換します。
+func Parse(s string) (Lang, error) {
+	switch l := Lang(strings.ToLower(s)); l {
+	case Ja, En:
+		return l, nil
+	}
+	return "", fmt.Errorf("不明な言語 '%s': ja か en を指定してください (unknown language: use ja or en)", s)
+}
+
+// Detect は -lang の値、なければ環境変数 LANG から言語を決めます。
+//
+// flagValue が空でなければ ja か en でなければなりません。
+// LANG は en_US.UTF-8 のように en で始まれば英語とし、それ以外と未設定は日本語とします。
+func Detect(flagValue, env string) (Lang, error) {
+	if flagValue != "" {
+		return Parse(flagValue)
+	}
+	if strings.HasPrefix(strings.ToLower(env), string(En)) {
+		return En, nil
+	}
+	return Ja, nil
+}
+
+// SetLang は表示する言語を設定します。
+// 並列処理を始める前に呼ぶ必要があります。
+func SetLang(l Lang) {
+	current = l
+}
+
+// Current は表示する言語を返します。
+func Current() Lang {
+	return current
+}
+
+// translate : 日本語の書式文字列を表示する言語に翻訳する。翻訳がなければそのまま返す
+func translate(format string) string {
+	if s, ok := catalogs[current][format]; ok {
+		return s
+	}
+	return format
+}
+
+// T は日本語の書式文字列formatを表示する言語に翻訳し、引数があれば fmt.Sprintf で書式化します。
+func T(format string, a ...any) string {
+	s := translate(format)
+	if len(a) == 0 {
+		return s
+	}
+	return fmt.Sprintf(s, a...)
+}
+
+// Errorf は日本語の書式文字列formatを表示する言語に翻訳し、fmt.Errorf でエラーを作ります。
+// %w でエラーをラップできます。
+func Errorf(format string, a ...any) error {
+	return fmt.Errorf(translate(format), a...)
+}
@@ -0,0 +1,284 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Lang
+		wantErr bool
+	}{
+		{"ja", Ja, false},
+		{"en", En, false},
+		{"EN", En, false},
+		{"fr", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) = %q, %v, want %q, wantErr %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name, flag, env string
+		want            Lang
+		wantErr         bool
+	}{
+		{"フラグが優先", "ja", "en_US.UTF-8", Ja, false},
+		{"フラグで英語", "en", "ja_JP.UTF-8", En, false},
+		{"LANGが英語", "", "en_US.UTF-8", En, false},
+		{"LANGが日本語", "", "ja_JP.UTF-8", Ja, false},
+		{"LANGがC", "", "C", Ja, false},
+		{"どちらもなし", "", "", Ja, false},
+		{"フラグが不正", "de", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Detect(tt.flag, tt.env)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("Detect(%q, %q) = %q, %v, want %q, wantErr %v", tt.flag, tt.env, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	defer SetLang(Current())
+	tests := []struct {
+		lang   Lang
+		format string
+		args   []any
+		want   string
+	}{
+		{Ja, "%d行目", []any{3}, "3行目"},
+		{En, "%d行目", []any{3}, "row 3"},
+		{En, "翻訳のないメッセージ", nil, "翻訳のないメッセージ"},
+		{En, "休日です", nil, "falls on a holiday"},
+		{En, "100%% です", nil, "100%% です"}, // 引数がなければ書式化しない
+	}
+	for _, tt := range tests {
+		SetLang(tt.lang)
+		if got := T(tt.format, tt.args...); got != tt.want {
+			t.Errorf("[%s] T(%q, %v) = %q, want %q", tt.lang, tt.format, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestErrorf(t *testing.T) {
+	defer SetLang(Current())
+	SetLang(En)
+	inner := os.ErrNotExist
+	err := Errorf("ファイル情報読み込みエラー: %w\n", inner)
+	if want := "cannot read file information: " + inner.Error() + "\n"; err.Error() != want {
+		t.Errorf("Errorf() = %q, want %q", err.Error(), want)
+	}
+	if !errors.Is(err, inner) {
+		t.Errorf("Errorf() が %v をラップしていません", inner)
+	}
+}
+
+// translated : 翻訳するメッセージを受け取る関数と、その書式文字列の引数の位置
+var translated = map[string]int{
+	"T":             0, // i18n.T
+	"Errorf":        0, // i18n.Errorf
+	"systemFinding": 1, // lib.systemFinding(severity, format, ...)
+	"fail":          0, // lib.(*fileJob).fail(format, ...)
+	"logf":          0, // input.(*Fixer).logf(format, ...)
+}
+
+// dynamicArgs : リテラル以外を書式文字列に渡してよい式
+// translated の関数の中で受け取った書式文字列を渡す場合と、dynamicKeys や description で集めるメッセージを渡す場合
+var dynamicArgs = []string{"format", "weekdayNames[d.Weekday()]", "r.description"}
+
+// dynamicKeys : 変数を介して T に渡すため、ソースコードから見つけられないメッセージ
+var dynamicKeys = []string{
+	"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日", // input.weekdayNames
+}
+
+// untranslated : 日本語のメッセージを渡すと翻訳されずに表示される関数
+var untranslated = map[string][]string{
+	"fmt":    {"Errorf", "Sprintf", "Printf", "Println", "Fprintf", "Fprintln"},
+	"errors": {"New"},
+	"slog":   {"Debug", "Info", "Warn", "Error"},
+	"log":    {"Print", "Printf", "Println", "Fatal", "Fatalf", "Fatalln"},
+}
+
+// sourceKeys : ソースコードとテンプレートで翻訳しているメッセージを集める
+// 翻訳を通さずに表示する日本語のメッセージと、リテラル以外を渡して翻訳を確かめられない呼び出しは problems に集める
+func sourceKeys(t *testing.T) (keys map[string]string, problems []string) {
+	t.Helper()
+	keys = make(map[string]string) // メッセージ → 場所
+	for _, k := range dynamicKeys {
+		keys[k] = "dynamicKeys"
+	}
+	fset := token.NewFileSet()
+	add := func(e ast.Expr) {
+		if s, ok := stringLit(e); ok {
+			keys[s] = fset.Position(e.Pos()).String()
+		}
+	}
+	root := filepath.Join("..", "..")
+	files, _ := filepath.Glob(filepath.Join(root, "*.go"))
+	err := filepath.WalkDir(filepath.Join(root, "lib"), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(path, ".go") {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") || strings.Contains(path, filepath.Join("lib", "i18n")) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				var name string
+				switch fun := n.Fun.(type) {
+				case *ast.Ident:
+					name = fun.Name
+				case *ast.SelectorExpr:
+					name = fun.Sel.Name
+					if x, ok := fun.X.(*ast.Ident); ok && x.Name != "i18n" {
+						if slices.Contains(untranslated[x.Name], name) && len(n.Args) > 0 {
+							if s, ok := stringLit(n.Args[0]); ok && isJapanese(s) {
+								problems = append(problems, fmt.Sprintf("%s: 翻訳されないメッセージです: %s.%s(%q)",
+									fset.Position(n.Pos()), x.Name, name, s))
+							}
+						}
+						if name == "T" || name == "Errorf" {
+							return true // fmt.Errorf など
+						}
+					}
+				}
+				if i, ok := translated[name]; ok && i < len(n.Args) {
+					if _, ok := stringLit(n.Args[i]); !ok && !slices.Contains(dynamicArgs, types.ExprString(n.Args[i])) {
+						problems = append(problems, fmt.Sprintf("%s: %s の書式文字列がリテラルではありません", fset.Position(n.Pos()), name))
+					}
+					add(n.Args[i])
+				}
+			case *ast.KeyValueExpr:
+				// ルールの説明は Description で翻訳する
+				if k, ok := n.Key.(*ast.Ident); ok && k.Name == "description" {
+					add(n.Value)
+				}
+			}
+			return true
+		})
+	}
+
+	b, err := os.ReadFile(filepath.Join(root, "lib", "output", "report.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range regexp.MustCompile(`\{\{T ("(?:[^"\\]|\\.)*")`).FindAllSubmatch(b, -1) {
+		s, err := strconv.Unquote(string(m[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[s] = "report.tmpl"
+	}
+	return keys, problems
+}
+
+// isJapanese : sが漢字・ひらがな・カタカナを含んでいればtrue
+func isJapanese(s string) bool {
+	return strings.ContainsFunc(s, func(r rune) bool { return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) })
+}
+
+// stringLit : 文字列リテラル、またはリテラルを + でつないだ式の値
+func stringLit(e ast.Expr) (string, bool) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(e.Value)
+		return s, err == nil
+	case *ast.BinaryExpr:
+		x, ok1 := stringLit(e.X)
+		y, ok2 := stringLit(e.Y)
+		return x + y, ok1 && ok2 && e.Op == token.ADD
+	}
+	return "", false
+}
+
+// verbs : 書式文字列の % 指定子 (%% を除く)
+var verbs = regexp.MustCompile(`%(?:\[\d+\])?[-+# 0]*\d*(?:\.\d+)?[a-zA-Z]`)
+
+// verbSet : 書式文字列が受け取る引数の位置と種類。語順を変える翻訳のため %[n]v を解釈する
+func verbSet(format string) map[int]string {
+	set := make(map[int]string)
+	n := 0
+	for _, v := range verbs.FindAllString(strings.ReplaceAll(format, "%%", ""), -1) {
+		if m := regexp.MustCompile(`^%\[(\d+)\]`).FindStringSubmatch(v); m != nil {
+			n, _ = strconv.Atoi(m[1])
+			n--
+		}
+		set[n] = v[len(v)-1:]
+		n++
+	}
+	return set
+}
+
+func TestCatalogCoversSource(t *testing.T) {
+	en := catalogs[En]
+	keys, problems := sourceKeys(t)
+	if len(keys) < 100 {
+		t.Fatalf("メッセージが %d 件しか見つかりません。ソースコードの探し方を確認してください", len(keys))
+	}
+	for k, pos := range keys {
+		if _, ok := en[k]; !ok {
+			t.Errorf("%s: 英語の翻訳がありません: %q", pos, k)
+		}
+	}
+	for k := range en {
+		if _, ok := keys[k]; !ok {
+			t.Errorf("使われていない翻訳があります: %q", k)
+		}
+	}
+	for _, p := range problems {
+		t.Error(p)
+	}
+}
+
+func TestCatalogVerbs(t *testing.T) {
+	for k, v := range catalogs[En] {
+		want, got := verbSet(k), verbSet(v)
+		if len(want) != len(got) {
+			t.Errorf("%q: 翻訳 %q の引数の数が違います", k, v)
+			continue
+		}
+		for i, verb := range want {
+			if got[i] != verb {
+				t.Errorf("%q: 翻訳 %q の%d番目の引数が %%%s ではありません", k, v, i+1, verb)
+			}
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package input
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"pncheck/lib/config"
+	"pncheck/lib/expr"
+	"pncheck/lib/i18n"
+)
+
+// exprField : 独自ルールの式で使える項目
+type exprField struct {
+	name  string
+	cell  string // ヘッダーはセル番地、明細は列。対応するセルがなければ空文字
+	value func(h *Header, o *Order) any
+}
+
+// headerExprFields : 入力Ⅱから読み取った項目
+var headerExprFields = []exprField{
+	{"製番", projectIDCell, func(h *Header, _ *Order) any { return h.ProjectID }},
+	{"製番名称", projectNameCell, func(h *Header, _ *Order) any { return h.ProjectName }},
+	{"製番納期", deadlineHCell, func(h *Header, _ *Order) any { return h.Deadline }},
+	{"要求年月日", requestDateCell, func(h *Header, _ *Order) any { return h.RequestDate }},
+	{"要求元", userSectionCell, func(h *Header, _ *Order) any { return h.UserSection }},
+	{"備考", noteCell, func(h *Header, _ *Order) any { return h.Note }},
+	{"発注区分", "", func(h *Header, _ *Order) any { return string(h.OrderType) }},
+	{"出庫指示番号", "", func(h *Header, _ *Order) any { return h.Remark }},
+	{"号機", "", func(h *Header, _ *Order) any { return h.Serial }},
+}
+
+// orderExprFields : 入力Ⅰの明細1行の項目
+// ヘッダーと同じ名前の項目(号機)は明細の値を優先する
+var orderExprFields = []exprField{
+	{"行", "", func(_ *Header, o *Order) any { return float64(o.Row) }},
+	{"Lv", colLv, func(_ *Header, o *Order) any { return float64(o.Lv) }},
+	{"品番", colPid, func(_ *Header, o *Order) any { return o.Pid }},
+	{"品名", colName, func(_ *Header, o *Order) any { return o.Name }},
+	{"型式", colType, func(_ *Header, o *Order) any { return o.Type }},
+	{"数量", colQuantity, func(_ *Header, o *Order) any { return o.Quantity }},
+	{"単位", colUnit, func(_ *Header, o *Order) any { return o.Unit }},
+	{"要望納期", colDeadlineO, func(_ *Header, o *Order) any { return o.Deadline }},
+	{"検区", colKenku, func(_ *Header, o *Order) any { return o.Kenku }},
+	{"装置名", colDevice, func(_ *Header, o *Order) any { return o.Device }},
+	{"号機", colSerial, func(_ *Header, o *Order) any { return o.Serial }},
+	{"メーカ", colMaker, func(_ *Header, o *Order) any { return o.Maker }},
+	{"要望先", colVendor, func(_ *Header, o *Order) any { return o.Vendor }},
+	{"予定単価", colUnitPrice, func(_ *Header, o *Order) any { return o.UnitPrice }},
+}
+
+// messagePlaceholder : メッセージに項目の値を埋め込む {品番} のような記法
+var messagePlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// customRule : 設定ファイルの custom_rules で定義したルール
+type customRule struct {
+	cfg      config.CustomRule
+	severity Severity
+	when     *expr.Expr // nilならすべてが対象
+	assert   *expr.Expr
+	fields   []exprField // 式で使える項目。後の項目が優先される
+}
+
+func init() {
+	Register(customRules)
+}
+
+// customRules : 設定ファイルの独自ルールを作る
+func customRules(cfg *config.Config) (rules []Rule, err error) {
+	for _, c := range cfg.CustomRules {
+		r, err := newCustomRule(c)
+		if err != nil {
+			return nil, fmt.Errorf("custom_rules.%s: %w", c.ID, err)
+		}
+		rules = append(rules, r)
+	}
+	return
+}
+
+// newCustomRule : 式を解析し、使えない項目名がないか確認する
+func newCustomRule(c config.CustomRule) (*customRule, error) {
+	r := &customRule{cfg: c, severity: SeverityError, fields: headerExprFields}
+	if c.Scope != config.ScopeHeader {
+		r.fields = append(append([]exprField{}, headerExprFields...), orderExprFields...)
+	}
+	if c.Severity != "" {
+		sev, err := ParseSeverity(c.Severity)
+		if err != nil {
+			return nil, err
+		}
+		r.severity = sev
+	}
+
+	var err error
+	if c.When != "" {
+		if r.when, err = expr.Parse(c.When); err != nil {
+			return nil, fmt.Errorf("when: %w", err)
+		}
+	}
+	if r.assert, err = expr.Parse(c.Assert); err != nil {
+		return nil, fmt.Errorf("assert: %w", err)
+	}
+
+	names := r.assert.Idents()
+	if r.when != nil {
+		names = append(names, r.when.Idents()...)
+	}
+	for _, m := range messagePlaceholder.FindAllStringSubmatch(c.Message, -1) {
+		names = append(names, m[1])
+	}
+	for _, name := range names {
+		if _, ok := r.field(name); !ok {
+			return nil, i18n.Errorf("使えない項目名 '%s'", name)
+		}
+	}
+	return r, nil
+}
+
+func (r *customRule) ID() string                { return r.cfg.ID }
+func (r *customRule) Description() string       { return i18n.T("独自ルール: %s", r.cfg.Assert) }
+func (r *customRule) DefaultSeverity() Severity { return r.severity }
+
+// Check : ヘッダーまたは明細の各行で式を評価する
+// 式の評価に失敗した場合は、同じ失敗を行ごとに繰り返さないよう1件だけ報告する
+func (r *customRule) Check(_ *Workbook, sheet *Sheet) (findings []Finding) {
+	if r.cfg.Scope == config.ScopeHeader {
+		f, err := r.check(&sheet.Header, nil)
+		if err != nil {
+			return []Finding{{Message: i18n.T("独自ルールを評価できません: %v", err)}}
+		}
+		if f != nil {
+			findings = append(findings, *f)
+		}
+		return
+	}
+
+	for i := range sheet.Orders {
+		f, err := r.check(&sheet.Header, &sheet.Orders[i])
+		if err != nil {
+			return append(findings, Finding{
+				Message: i18n.T("独自ルールを評価できません (%d行目): %v", sheet.Orders[i].Row, err),
+			})
+		}
+		if f != nil {
+			findings = append(findings, *f)
+		}
+	}
+	return
+}
+
+// check : 1件のヘッダーまたは明細について評価し、条件を満たさなければFindingを返す
+func (r *customRule) check(h *Header, o *Order) (*Finding, error) {
+	env := make(expr.Env, len(r.fields))
+	for _, fd := range r.fields {
+		env[fd.name] = fd.value(h, o)
+	}
+	if r.when != nil {
+		ok, err := r.when.EvalBool(env)
+		if err != nil || !ok {
+			return nil, err
+		}
+	}
+	ok, err := r.assert.EvalBool(env)
+	if err != nil || ok {
+		return nil, err
+	}
+
+	msg := messagePlaceholder.ReplaceAllStringFunc(r.cfg.Message, func(m string) string {
+		return expr.Format(env[m[1:len(m)-1]])
+	})
+	if o == nil {
+		// 条件式で最初に参照したセルのある項目を問題の場所とする
+		var cell string
+		for _, name := range r.assert.Idents() {
+			if fd, _ := r.field(name); fd.cell != "" {
+				cell = fd.cell
+				break
+			}
+		}
+		f := &Finding{Sheet: headerSheetName, Cell: cell, Message: msg}
+		if cell != "" {
+			f.Message = fmt.Sprintf("%s %s: %s", headerSheetName, cell, msg)
+		}
+		return f, nil
+	}
+	f := &Finding{
+		Sheet:   orderSheetName,
+		Row:     o.Row,
+		Message: i18n.T("明細(%s) %d行目: %s", orderSheetName, o.Row, msg),
+	}
+	// ヘッダーの項目はセル番地、明細の項目は列で持つ
+	if fd, _ := r.field(firstOrderIdent(r.assert)); fd.cell != "" {
+		f.Cell = fd.cell + strconv.Itoa(o.Row)
+	}
+	return f, nil
+}
+
+// field : 名前の項目を返す。同じ名前は後の項目を優先する
+func (r *customRule) field(name string) (exprField, bool) {
+	for i := len(r.fields) - 1; i >= 0; i-- {
+		if r.fields[i].name == name {
+			return r.fields[i], true
+		}
+	}
+	return exprField{}, false
+}
+
+// firstOrderIdent : 式で最初に参照した明細の項目名
+func firstOrderIdent(e *expr.Expr) string {
+	for _, name := range e.Idents() {
+		for _, fd := range orderExprFields {
+			if fd.name == name && fd.cell != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
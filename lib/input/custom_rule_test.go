@@ -0,0 +1,98 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+
+	"pncheck/lib/config"
+)
+
+func TestCustomRule_Check(t *testing.T) {
+	sheet := &Sheet{
+		Header: Header{OrderType: 外注, ProjectID: "123456789000", RequestDate: "2025/04/01"},
+		Orders: Orders{
+			{Pid: "AB-1", Quantity: 1, Unit: "個", Kenku: "A", Row: 2},
+			{Pid: "AB-2", Quantity: 0, Unit: "箱", Kenku: "C", Row: 3},
+		},
+	}
+
+	tests := []struct {
+		name string
+		rule config.CustomRule
+		want []Finding
+	}{
+		{
+			name: "明細ごとに評価してセル番地を示す",
+			rule: config.CustomRule{ID: "qty", Assert: "数量 > 0", Message: "{品番} の数量が0です"},
+			want: []Finding{{Sheet: orderSheetName, Row: 3, Cell: "I3", Message: "明細(入力Ⅰ) 3行目: AB-2 の数量が0です"}},
+		},
+		{
+			name: "whenで対象を絞り込む",
+			rule: config.CustomRule{
+				ID: "kenku", When: `発注区分 == "外注"`, Assert: `検区 in ["A", "B"]`,
+				Message: "外注の検区はAかBです",
+			},
+			want: []Finding{{Sheet: orderSheetName, Row: 3, Cell: "K3", Message: "明細(入力Ⅰ) 3行目: 外注の検区はAかBです"}},
+		},
+		{
+			name: "whenに一致しなければ評価しない",
+			rule: config.CustomRule{
+				ID: "kenku", When: `発注区分 == "購入"`, Assert: `検区 in ["A", "B"]`,
+				Message: "購入の検区はAかBです",
+			},
+			want: nil,
+		},
+		{
+			name: "ヘッダーは1回だけ評価する",
+			rule: config.CustomRule{
+				ID: "date", Scope: config.ScopeHeader, Assert: `要求年月日 >= "2025/05/01"`,
+				Message: "要求年月日が古すぎます",
+			},
+			want: []Finding{{Sheet: headerSheetName, Cell: requestDateCell, Message: "入力Ⅱ D4: 要求年月日が古すぎます"}},
+		},
+		{
+			name: "評価できない式は1件だけ報告する",
+			rule: config.CustomRule{ID: "bad", Assert: "単位 > 0", Message: "m"},
+			want: []Finding{{Message: `独自ルールを評価できません (2行目): 式 '単位 > 0': "個" は数値ではありません`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newCustomRule(tt.rule)
+			if err != nil {
+				t.Fatalf("newCustomRule() error = %v", err)
+			}
+			if got := r.Check(nil, sheet); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCustomRule_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.CustomRule
+	}{
+		{"構文エラー", config.CustomRule{ID: "x", Assert: "数量 >", Message: "m"}},
+		{"存在しない項目名", config.CustomRule{ID: "x", Assert: "在庫 > 0", Message: "m"}},
+		{"ヘッダーで明細の項目", config.CustomRule{ID: "x", Scope: config.ScopeHeader, Assert: "数量 > 0", Message: "m"}},
+		{"メッセージに存在しない項目名", config.CustomRule{ID: "x", Assert: "数量 > 0", Message: "{在庫}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newCustomRule(tt.rule); err == nil {
+				t.Error("newCustomRule() error = nil, want error")
+			}
+		})
+	}
+
+	t.Run("組み込みルールとIDが重複", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.CustomRules = []config.CustomRule{{ID: "sort-order", Assert: "true", Message: "m"}}
+		if _, err := NewEngine(cfg); err == nil {
+			t.Error("NewEngine() error = nil, want error")
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package input
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/i18n"
+)
+
+var (
+	// PNSearch規格外の日付文字列
+	dateLayoutSub = []string{"01-02-06", "2006/1/2", "1/2/2006", "2006-1-2", "2006.1.2", "20060102"}
+
+	// 令和7年4月1日, R7.4.1, H31/4/30 のような和暦表記
+	eraDatePattern = regexp.MustCompile(
+		`^(令和|平成|昭和|[RrHhSs])\s*(元|\d{1,2})\s*[年./-]\s*(\d{1,2})\s*[月./-]\s*(\d{1,2})\s*日?$`)
+	// 2025年4月1日 のような年月日表記
+	kanjiDatePattern = regexp.MustCompile(`^(\d{4})\s*年\s*(\d{1,2})\s*月\s*(\d{1,2})\s*日$`)
+
+	// 全角の数字と日付の区切り文字を半角にする
+	fullWidthDateReplacer = strings.NewReplacer(
+		"０", "0", "１", "1", "２", "2", "３", "3", "４", "4",
+		"５", "5", "６", "6", "７", "7", "８", "8", "９", "9",
+		"／", "/", "－", "-", "．", ".", "　", " ",
+		"Ｒ", "R", "Ｈ", "H", "Ｓ", "S",
+	)
+
+	// errInvalidDate : 日付として解釈できない文字列
+	errInvalidDate error = invalidDateError{}
+)
+
+// invalidDateError : 日付として解釈できない文字列のエラー
+// パッケージ初期化時には表示言語が決まっていないため、表示するときに翻訳する
+type invalidDateError struct{}
+
+func (invalidDateError) Error() string {
+	return i18n.T("日付として解釈できません")
+}
+
+// era : 元号と元年の初日
+type era struct {
+	names []string
+	start time.Time
+}
+
+// eras : 和暦表記で解釈する元号
+var eras = []era{
+	{[]string{"令和", "R", "r"}, time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC)},
+	{[]string{"平成", "H", "h"}, time.Date(1989, time.January, 8, 0, 0, 0, 0, time.UTC)},
+	{[]string{"昭和", "S", "s"}, time.Date(1926, time.December, 25, 0, 0, 0, 0, time.UTC)},
+}
+
+// excelMaxSerial : Excelが扱える最大の日付 9999/12/31 のシリアル値
+const excelMaxSerial = 2958465
+
+// PNSearchが求める日付の文字列型を修正して返す
+//
+// まずはDateLayout, dateLayoutSub に定めた文字列型として解釈し、
+// 次に和暦(令和7年4月1日, R7.4.1)と年月日表記を解釈し、
+// 最後にExcel日付型(シリアル値)として解釈する。
+// 全角数字は半角にしてから解釈する。
+// date1904 はワークブックが1904年を起点とする日付システムかどうか。
+//
+// 棒線や空欄は空文字を返し、
+// どの形式でも解釈できない文字列はエラーを返す。
+func parseDateSafe(s string, date1904 bool) (string, error) {
+	s = strings.TrimSpace(fullWidthDateReplacer.Replace(s))
+	switch s { // 棒線や空欄なら空文字を返す
+	case "", "‐", "-", "－", "―", "ー":
+		return "", nil
+	}
+
+	if _, err := time.Parse(DateLayout, s); err == nil {
+		return s, nil
+	}
+	for _, layoutSub := range dateLayoutSub {
+		// パースに成功したらPNSearch標準の文字列型で返す
+		if t, err := time.Parse(layoutSub, s); err == nil {
+			return t.Format(DateLayout), nil
+		}
+	}
+
+	if t, ok := parseEraDate(s); ok {
+		return t.Format(DateLayout), nil
+	}
+	if m := kanjiDatePattern.FindStringSubmatch(s); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		if t, ok := newDate(y, m[2], m[3]); ok {
+			return t.Format(DateLayout), nil
+		}
+	}
+
+	// 文字列型で読み込めなければExcelTime
+	if v, err := strconv.ParseFloat(s, 64); err == nil && v > 0 && v <= excelMaxSerial {
+		t := excelTimeToGoTime(v, date1904)
+		return t.Format(DateLayout), nil
+	}
+
+	return s, fmt.Errorf("%w: '%s'", errInvalidDate, s)
+}
+
+// parseEraDate : 和暦表記の日付を解釈する
+func parseEraDate(s string) (time.Time, bool) {
+	m := eraDatePattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, false
+	}
+	for _, e := range eras {
+		for _, name := range e.names {
+			if m[1] != name {
+				continue
+			}
+			n := 1 // 元年
+			if m[2] != "元" {
+				n, _ = strconv.Atoi(m[2])
+			}
+			t, ok := newDate(e.start.Year()+n-1, m[3], m[4])
+			// 元号の始まる前の日付は不正
+			if !ok || t.Before(e.start) {
+				return time.Time{}, false
+			}
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// newDate : 年と月日の文字列から日付を作成する
+// 2月30日のような存在しない日付はfalseを返す
+func newDate(year int, month, day string) (time.Time, bool) {
+	m, err := strconv.Atoi(month)
+	if err != nil {
+		return time.Time{}, false
+	}
+	d, err := strconv.Atoi(day)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t := time.Date(year, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	if t.Year() != year || int(t.Month()) != m || t.Day() != d {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// isDate1904 : ワークブックが1904年を起点とする日付システムを使っているか
+func isDate1904(f *excelize.File) bool {
+	props, err := f.GetWorkbookProps()
+	if err != nil || props.Date1904 == nil {
+		return false
+	}
+	return *props.Date1904
+}
+
+// Excelのシリアル値をGoの time.Time に変換するヘルパー関数
+// Excel Time型は整数部分が日数を、小数部分が時刻を表す
+func excelTimeToGoTime(excelSerialValue float64, date1904 bool) time.Time {
+	// 基準日 (1900年1月1日)
+	// Goの time.Time は1900年1月1日 00:00:00 (JST) から日数を加算する
+	baseTime := time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		// 1904年ベースの場合の起点は 1904/1/1 (シリアル値 0)
+		baseTime = time.Date(1904, time.January, 1, 0, 0, 0, 0, time.UTC)
+	} else {
+		// 1900年ベースの場合の起点は 1900/1/1 (シリアル値 1)
+		// Excelは存在しない1900/2/29を数えるため2日分ずらす
+		excelSerialValue -= 2.0
+	}
+	// 日数部分の計算
+	days := math.Floor(excelSerialValue)
+	// 秒数の計算 (24時間 * 3600秒/時間 = 86400秒/日)
+	seconds := math.Round((excelSerialValue - days) * 86400.0)
+
+	// 基準日から日数と秒数を加算して返す
+	t := baseTime.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+
+	return t
+}
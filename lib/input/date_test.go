@@ -0,0 +1,80 @@
+package input
+
+import (
+	"errors"
+	"testing"
+
+	"pncheck/lib/i18n"
+)
+
+func TestParseDateSafe(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		date1904 bool
+		want     string
+		wantErr  bool
+	}{
+		{"PNSearch標準", "2025/04/01", false, "2025/04/01", false},
+		{"ゼロ埋めなし", "2025/4/1", false, "2025/04/01", false},
+		{"米国式", "4/1/2025", false, "2025/04/01", false},
+		{"ハイフン区切り", "2025-04-01", false, "2025/04/01", false},
+		{"区切りなし", "20250401", false, "2025/04/01", false},
+		{"空欄", "", false, "", false},
+		{"棒線", "‐", false, "", false},
+		{"全角数字", "２０２５／０４／０１", false, "2025/04/01", false},
+		{"年月日", "2025年4月1日", false, "2025/04/01", false},
+		{"全角の年月日", "２０２５年４月１日", false, "2025/04/01", false},
+		{"令和", "令和7年4月1日", false, "2025/04/01", false},
+		{"令和元年", "令和元年5月1日", false, "2019/05/01", false},
+		{"令和略記", "R7.4.1", false, "2025/04/01", false},
+		{"令和略記スラッシュ", "R7/4/1", false, "2025/04/01", false},
+		{"平成", "平成31年4月30日", false, "2019/04/30", false},
+		{"平成略記", "H31.4.30", false, "2019/04/30", false},
+		{"令和改元前", "令和元年4月30日", false, "", true},
+		{"存在しない日付", "2025年2月30日", false, "", true},
+		{"Excelシリアル値", "45748", false, "2025/04/01", false},
+		{"Excelシリアル値 1904年起点", "44286", true, "2025/04/01", false},
+		{"解釈できない文字列", "来週中", false, "", true},
+		{"範囲外のシリアル値", "99999999", false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDateSafe(tt.input, tt.date1904)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDateSafe(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidDate) {
+					t.Errorf("parseDateSafe(%q) error = %v, want errInvalidDate", tt.input, err)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseDateSafe(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateSafe_Lang(t *testing.T) {
+	defer i18n.SetLang(i18n.Current())
+	tests := []struct {
+		lang i18n.Lang
+		want string
+	}{
+		{i18n.Ja, "日付として解釈できません: '来週中'"},
+		{i18n.En, "cannot be interpreted as a date: '来週中'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.lang), func(t *testing.T) {
+			i18n.SetLang(tt.lang)
+			_, err := parseDateSafe("来週中", false)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("parseDateSafe() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package input
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"pncheck/lib/config"
+	"pncheck/lib/i18n"
+)
+
+func init() {
+	Register(deadlineRules)
+}
+
+// deadlineRules : 設定を反映した要望納期のルールを作る
+func deadlineRules(cfg *config.Config) ([]Rule, error) {
+	cal, err := loadCalendar(cfg.Deadline.Calendar)
+	if err != nil {
+		return nil, err
+	}
+	maxYears := cfg.Deadline.MaxYears
+	return []Rule{
+		ruleFunc{
+			id:          "deadline",
+			description: i18n.T("要望納期が要求年月日から製番納期までの間にあり、%d年以内であること", maxYears),
+			severity:    SeverityError,
+			check: func(_ *Workbook, sheet *Sheet) []Finding {
+				return validateDeadlines(sheet, maxYears)
+			},
+		},
+		ruleFunc{
+			id:          "deadline-holiday",
+			description: "要望納期が会社の休日でないこと (設定ファイルの deadline.calendar を指定した場合のみ)",
+			severity:    SeverityWarning,
+			check: func(_ *Workbook, sheet *Sheet) []Finding {
+				return cal.validate(sheet)
+			},
+		},
+	}, nil
+}
+
+// validateDeadlines は明細の要望納期について、
+// 要求年月日より前でないこと、製番納期より後でないこと、
+// 要求年月日からmaxYears年より先でないことを検証し、問題のある行ごとにFindingを返します。
+// 空欄の日付は比較しません。
+func validateDeadlines(sheet *Sheet, maxYears int) (findings []Finding) {
+	request, _ := time.Parse(DateLayout, sheet.RequestDate)
+	project, _ := time.Parse(DateLayout, sheet.Header.Deadline)
+	for _, o := range sheet.Orders {
+		d, err := time.Parse(DateLayout, o.Deadline)
+		if err != nil {
+			continue
+		}
+		var reason string
+		switch {
+		case !request.IsZero() && d.Before(request):
+			reason = i18n.T("要求年月日 %s より前です", sheet.RequestDate)
+		case !project.IsZero() && d.After(project):
+			reason = i18n.T("製番納期 %s より後です", sheet.Header.Deadline)
+		case !request.IsZero() && d.After(request.AddDate(maxYears, 0, 0)):
+			reason = i18n.T("要求年月日 %s から%d年より先です", sheet.RequestDate, maxYears)
+		default:
+			continue
+		}
+		findings = append(findings, deadlineFinding(o, reason))
+	}
+	return
+}
+
+// deadlineFinding : 要望納期のセルを示すFinding
+func deadlineFinding(o Order, reason string) Finding {
+	return Finding{
+		Sheet: orderSheetName,
+		Row:   o.Row,
+		Cell:  colDeadlineO + strconv.Itoa(o.Row),
+		Message: i18n.T("明細(%s) %d行目: 要望納期(%s列) %s が%s",
+			orderSheetName, o.Row, colDeadlineO, o.Deadline, reason),
+	}
+}
+
+// calendar : 会社の休日
+type calendar struct {
+	weekend  bool
+	holidays map[string]string // YYYY/MM/DD → 休日の名前
+}
+
+// loadCalendar : 休日ファイルを読み込む
+//
+// 1行に1日ずつ "2025/01/01 元日" のように日付と任意の名前を書く。
+// 日付は要求票と同じく和暦や区切りの違いも解釈する。# 以降はコメント。
+func loadCalendar(cfg config.Calendar) (*calendar, error) {
+	cal := &calendar{weekend: cfg.Weekend, holidays: make(map[string]string)}
+	if cfg.File == "" {
+		return cal, nil
+	}
+	f, err := os.Open(cfg.File)
+	if err != nil {
+		return nil, i18n.Errorf("休日ファイルを開けません: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		d, err := parseDateSafe(fields[0], false)
+		if err != nil || d == "" {
+			return nil, i18n.Errorf("休日ファイル '%s' の%d行目の日付が不正です: %s", cfg.File, n, fields[0])
+		}
+		cal.holidays[d] = strings.Join(fields[1:], " ")
+	}
+	if err := sc.Err(); err != nil {
+		return nil, i18n.Errorf("休日ファイル '%s' を読み込めません: %w", cfg.File, err)
+	}
+	return cal, nil
+}
+
+// validate : 要望納期が休日の明細ごとにFindingを返す
+func (cal *calendar) validate(sheet *Sheet) (findings []Finding) {
+	for _, o := range sheet.Orders {
+		d, err := time.Parse(DateLayout, o.Deadline)
+		if err != nil {
+			continue
+		}
+		if name, ok := cal.holidays[o.Deadline]; ok {
+			reason := i18n.T("休日です")
+			if name != "" {
+				reason = i18n.T("休日(%s)です", name)
+			}
+			findings = append(findings, deadlineFinding(o, reason))
+			continue
+		}
+		if cal.weekend && (d.Weekday() == time.Saturday || d.Weekday() == time.Sunday) {
+			findings = append(findings, deadlineFinding(o, i18n.T("%sです", i18n.T(weekdayNames[d.Weekday()]))))
+		}
+	}
+	return
+}
+
+// weekdayNames : 曜日の表示名
+var weekdayNames = [...]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"}
@@ -0,0 +1,86 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"pncheck/lib/config"
+)
+
+func TestValidateDeadlines(t *testing.T) {
+	sheet := &Sheet{
+		Header: Header{RequestDate: "2025/04/01", Deadline: "2025/12/31"},
+		Orders: Orders{
+			{Deadline: "2025/04/10", Row: 2}, // 正常
+			{Deadline: "2025/03/31", Row: 3}, // 要求年月日より前
+			{Deadline: "2026/01/05", Row: 4}, // 製番納期より後
+			{Deadline: "", Row: 5},           // 空欄は比較しない
+			{Deadline: "2025/04/01", Row: 6}, // 要求年月日と同日
+			{Deadline: "2025/12/31", Row: 7}, // 製番納期と同日
+		},
+	}
+	var got []string
+	for _, f := range validateDeadlines(sheet, 3) {
+		got = append(got, f.Cell)
+	}
+	if want := []string{"J3", "J4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("validateDeadlines() cells = %v, want %v", got, want)
+	}
+
+	// 製番納期が空欄なら遠すぎる日付を確認する
+	sheet.Header.Deadline = ""
+	sheet.Orders = Orders{{Deadline: "2028/04/01", Row: 2}, {Deadline: "2028/04/02", Row: 3}}
+	got = nil
+	for _, f := range validateDeadlines(sheet, 3) {
+		got = append(got, f.Cell)
+	}
+	if want := []string{"J3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("validateDeadlines() cells = %v, want %v", got, want)
+	}
+}
+
+func TestCalendar_Validate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.txt")
+	content := "# 会社の休日\n2025/04/29 昭和の日\n令和7年5月1日\n\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cal, err := loadCalendar(config.Calendar{Weekend: true, File: path})
+	if err != nil {
+		t.Fatalf("loadCalendar() error = %v", err)
+	}
+
+	sheet := &Sheet{Orders: Orders{
+		{Deadline: "2025/04/28", Row: 2}, // 月曜日
+		{Deadline: "2025/04/29", Row: 3}, // 祝日
+		{Deadline: "2025/05/01", Row: 4}, // 名前のない休日
+		{Deadline: "2025/05/03", Row: 5}, // 土曜日
+	}}
+	var got []string
+	for _, f := range cal.validate(sheet) {
+		got = append(got, f.Message)
+	}
+	want := []string{
+		"明細(入力Ⅰ) 3行目: 要望納期(J列) 2025/04/29 が休日(昭和の日)です",
+		"明細(入力Ⅰ) 4行目: 要望納期(J列) 2025/05/01 が休日です",
+		"明細(入力Ⅰ) 5行目: 要望納期(J列) 2025/05/03 が土曜日です",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("calendar.validate() = %v, want %v", got, want)
+	}
+
+	// 設定がなければ確認しない
+	cal, _ = loadCalendar(config.Calendar{})
+	if f := cal.validate(sheet); f != nil {
+		t.Errorf("calendar.validate() = %v, want nil", f)
+	}
+
+	if err := os.WriteFile(path, []byte("来週\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCalendar(config.Calendar{File: path}); err == nil {
+		t.Error("不正な日付でエラーが返されませんでした")
+	}
+}
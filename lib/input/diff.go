@@ -0,0 +1,85 @@
+package input
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"pncheck/lib/expr"
+	"pncheck/lib/i18n"
+)
+
+// FieldDiff : pncheckが読み込んだSheetとPNSearchが返したSheetで値が異なる項目
+type FieldDiff struct {
+	Sheet  string // 入力Ⅱ(ヘッダー) または 入力Ⅰ(明細)
+	Row    int    // 明細の行番号。ヘッダーは0
+	Cell   string // 対応するセル。なければ空文字
+	Field  string // 項目名
+	Local  string // pncheckが読み込んだ値
+	Server string // PNSearchが返した値
+}
+
+// Location : レポートに表示する場所 (例: "入力Ⅰ 3行目 F3")
+func (d FieldDiff) Location() string {
+	s := d.Sheet
+	if d.Row > 0 {
+		s += i18n.T(" %d行目", d.Row)
+	}
+	if d.Cell != "" {
+		s += " " + d.Cell
+	}
+	return s
+}
+
+// String : レポート表示用の文字列
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s %s: %s → %s", d.Location(), d.Field, strconv.Quote(d.Local), strconv.Quote(d.Server))
+}
+
+// diffOrderFields : 比較する明細の項目。行番号はPNSearchへ送らないので除く
+var diffOrderFields = slices.DeleteFunc(slices.Clone(orderExprFields), func(fd exprField) bool { return fd.name == "行" })
+
+// DiffSheets はヘッダーと明細の項目ごとにlocalとserverの値を比較し、異なる項目を返します。
+//
+// PNSearchは明細を送った順に返すので、同じ位置の明細を比較する。
+// 明細の数が異なる場合、片方にしかない明細は項目名を "明細" として示す。
+func DiffSheets(local, server *Sheet) (diffs []FieldDiff) {
+	for _, fd := range headerExprFields {
+		l := expr.Format(fd.value(&local.Header, nil))
+		s := expr.Format(fd.value(&server.Header, nil))
+		if l != s {
+			diffs = append(diffs, FieldDiff{Sheet: headerSheetName, Cell: fd.cell, Field: fd.name, Local: l, Server: s})
+		}
+	}
+
+	lastRow := ordersStartRow - 1
+	if n := len(local.Orders); n > 0 {
+		lastRow = local.Orders[n-1].Row
+	}
+	for i := range max(len(local.Orders), len(server.Orders)) {
+		// PNSearchにしかない明細は最後の明細に続く行とみなす
+		row := lastRow + i - len(local.Orders) + 1
+		if i < len(local.Orders) {
+			row = local.Orders[i].Row
+		}
+		switch {
+		case i >= len(server.Orders):
+			diffs = append(diffs, FieldDiff{Sheet: orderSheetName, Row: row, Field: "明細",
+				Local: local.Orders[i].Pid, Server: ""})
+			continue
+		case i >= len(local.Orders):
+			diffs = append(diffs, FieldDiff{Sheet: orderSheetName, Row: row, Field: "明細",
+				Local: "", Server: server.Orders[i].Pid})
+			continue
+		}
+		for _, fd := range diffOrderFields {
+			l := expr.Format(fd.value(&local.Header, &local.Orders[i]))
+			s := expr.Format(fd.value(&server.Header, &server.Orders[i]))
+			if l != s {
+				diffs = append(diffs, FieldDiff{Sheet: orderSheetName, Row: row,
+					Cell: fd.cell + strconv.Itoa(row), Field: fd.name, Local: l, Server: s})
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSheets(t *testing.T) {
+	local := &Sheet{
+		Header: Header{ProjectID: "123456789000", ProjectName: "試作"},
+		Orders: Orders{
+			{Pid: "PN-1", Name: "なっと", Quantity: 2, Unit: "ヶ", Row: 2},
+			{Pid: "PN-2", Name: "ボルト", Quantity: 1, Unit: "個", Row: 4},
+		},
+	}
+	server := &Sheet{
+		Header: Header{ProjectID: "123456789000", ProjectName: "試作機"},
+		Orders: Orders{
+			{Pid: "PN-1", Name: "ナット", Quantity: 2, Unit: "個"},
+			{Pid: "PN-2", Name: "ボルト", Quantity: 1.5, Unit: "個"},
+			{Pid: "PN-3", Name: "座金", Quantity: 1, Unit: "個"},
+		},
+	}
+	var got []string
+	for _, d := range DiffSheets(local, server) {
+		got = append(got, d.String())
+	}
+	want := []string{
+		`入力Ⅱ D5 製番名称: "試作" → "試作機"`,
+		`入力Ⅰ 2行目 F2 品名: "なっと" → "ナット"`,
+		`入力Ⅰ 2行目 BE2 単位: "ヶ" → "個"`,
+		`入力Ⅰ 4行目 I4 数量: "1" → "1.5"`,
+		`入力Ⅰ 5行目 明細: "" → "PN-3"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffSheets() = %q, want %q", got, want)
+	}
+
+	if d := DiffSheets(local, local); d != nil {
+		t.Errorf("DiffSheets() = %v, want nil", d)
+	}
+}
@@ -0,0 +1,150 @@
+package input
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"pncheck/lib/i18n"
+)
+
+// CrossFileDuplicateRuleID : ファイルをまたぐ重複のルールID
+// Engine.Run では検出せず、すべてのファイルを読み込んだ後に FindDuplicatesAcrossFiles で検出する
+const CrossFileDuplicateRuleID = "duplicate-across-files"
+
+func init() {
+	Register(static(
+		ruleFunc{
+			id:          "duplicate-line",
+			description: "1つの要求票に品番、要望納期、号機が同じ明細が複数ないこと",
+			severity:    SeverityWarning,
+			check: func(_ *Workbook, sheet *Sheet) []Finding {
+				return validateDuplicateLines(sheet)
+			},
+		},
+		ruleFunc{
+			id:          CrossFileDuplicateRuleID,
+			description: "同時に確認した要求票の間で製番と品番が同じ明細がないこと",
+			severity:    SeverityWarning,
+			check:       func(*Workbook, *Sheet) []Finding { return nil },
+		},
+	))
+}
+
+// lineKey : 重複とみなす明細の項目
+type lineKey struct {
+	pid, deadline, serial string
+}
+
+// validateDuplicateLines は品番、要望納期、号機が同じ明細をまとめ、
+// 重複ごとに行番号と合計数量を示すFindingを返します。品番が空の行は対象外です。
+func validateDuplicateLines(sheet *Sheet) (findings []Finding) {
+	groups := make(map[lineKey][]Order)
+	var keys []lineKey // 検出順を保つ
+	for _, o := range sheet.Orders {
+		if o.Pid == "" {
+			continue
+		}
+		k := lineKey{o.Pid, o.Deadline, o.Serial}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], o)
+	}
+
+	for _, k := range keys {
+		orders := groups[k]
+		if len(orders) < 2 {
+			continue
+		}
+		var rows []string
+		var total float64
+		for _, o := range orders {
+			rows = append(rows, i18n.T("%d行目", o.Row))
+			total += o.Quantity
+		}
+		// 2つ目以降の行が貼り付けの誤りである可能性が高いので、2つ目の行を示す
+		second := orders[1]
+		findings = append(findings, Finding{
+			Sheet: orderSheetName,
+			Row:   second.Row,
+			Cell:  colPid + strconv.Itoa(second.Row),
+			Message: i18n.T("明細(%s) 品番 %s, 要望納期 %s, 号機 %s の明細が重複しています: %s (合計数量 %g)",
+				orderSheetName, k.pid, k.deadline, k.serial, strings.Join(rows, ", "), total),
+		})
+	}
+	return
+}
+
+// FileSheet : ファイルをまたぐ確認のための、読み込み済みの要求票
+type FileSheet struct {
+	Path  string
+	Sheet *Sheet
+}
+
+// fileLine : ファイルをまたいで重複した明細の場所
+type fileLine struct {
+	path     string
+	row      int
+	quantity float64
+}
+
+// FindDuplicatesAcrossFiles は製番と品番が同じ明細を複数のファイルから探し、
+// ファイルパスごとのFindingを返します。
+// 1つのファイル内の重複は duplicate-line で報告するため、2つ以上のファイルにまたがるものだけを対象とします。
+func FindDuplicatesAcrossFiles(files []FileSheet) map[string][]Finding {
+	type key struct{ project, pid string }
+	groups := make(map[key][]fileLine)
+	var keys []key
+	for _, f := range files {
+		for _, o := range f.Sheet.Orders {
+			if o.Pid == "" {
+				continue
+			}
+			k := key{f.Sheet.ProjectID, o.Pid}
+			if _, ok := groups[k]; !ok {
+				keys = append(keys, k)
+			}
+			groups[k] = append(groups[k], fileLine{f.Path, o.Row, o.Quantity})
+		}
+	}
+
+	result := make(map[string][]Finding)
+	for _, k := range keys {
+		lines := groups[k]
+		paths := make(map[string]bool)
+		var total float64
+		for _, l := range lines {
+			paths[l.path] = true
+			total += l.quantity
+		}
+		if len(paths) < 2 {
+			continue
+		}
+
+		// 各ファイルには、そのファイルの最初の行と他のファイルの場所を示す
+		reported := make(map[string]bool)
+		for _, l := range lines {
+			if reported[l.path] {
+				continue
+			}
+			reported[l.path] = true
+			var others []string
+			for _, o := range lines {
+				if o.path != l.path {
+					others = append(others, i18n.T("%s %d行目 (数量 %g)",
+						filepath.Base(o.path), o.row, o.quantity))
+				}
+			}
+			result[l.path] = append(result[l.path], Finding{
+				RuleID: CrossFileDuplicateRuleID,
+				Sheet:  orderSheetName,
+				Row:    l.row,
+				Cell:   colPid + strconv.Itoa(l.row),
+				Message: i18n.T("明細(%s) %d行目: 製番 %s の品番 %s が他の要求票にもあります: %s (合計数量 %g)",
+					orderSheetName, l.row, k.project, k.pid, strings.Join(others, ", "), total),
+			})
+		}
+	}
+	return result
+}
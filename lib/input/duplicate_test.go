@@ -0,0 +1,67 @@
+package input
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateDuplicateLines(t *testing.T) {
+	sheet := &Sheet{Orders: Orders{
+		{Pid: "A", Deadline: "2025/04/10", Serial: "001", Quantity: 2, Row: 2},
+		{Pid: "B", Deadline: "2025/04/10", Serial: "001", Quantity: 1, Row: 3},
+		{Pid: "A", Deadline: "2025/04/10", Serial: "001", Quantity: 3, Row: 4},
+		{Pid: "A", Deadline: "2025/04/11", Serial: "001", Quantity: 1, Row: 5}, // 要望納期が異なる
+		{Pid: "", Deadline: "2025/04/10", Serial: "001", Quantity: 1, Row: 6},  // 品番なし
+		{Pid: "", Deadline: "2025/04/10", Serial: "001", Quantity: 1, Row: 7},
+	}}
+	findings := validateDuplicateLines(sheet)
+	if len(findings) != 1 {
+		t.Fatalf("validateDuplicateLines() = %v, want 1 finding", findings)
+	}
+	f := findings[0]
+	if f.Cell != "E4" {
+		t.Errorf("Cell = %q, want E4", f.Cell)
+	}
+	for _, want := range []string{"2行目, 4行目", "合計数量 5"} {
+		if !strings.Contains(f.Message, want) {
+			t.Errorf("Message = %q, want to contain %q", f.Message, want)
+		}
+	}
+}
+
+func TestFindDuplicatesAcrossFiles(t *testing.T) {
+	files := []FileSheet{
+		{Path: "dir/a.xlsx", Sheet: &Sheet{
+			Header: Header{ProjectID: "123456789000"},
+			Orders: Orders{{Pid: "A", Quantity: 2, Row: 2}, {Pid: "B", Quantity: 1, Row: 3}},
+		}},
+		{Path: "dir/b.xlsx", Sheet: &Sheet{
+			Header: Header{ProjectID: "123456789000"},
+			Orders: Orders{{Pid: "C", Quantity: 1, Row: 2}, {Pid: "A", Quantity: 3, Row: 4}},
+		}},
+		{Path: "dir/c.xlsx", Sheet: &Sheet{
+			Header: Header{ProjectID: "999999999000"}, // 製番が異なる
+			Orders: Orders{{Pid: "A", Quantity: 1, Row: 2}},
+		}},
+	}
+	got := FindDuplicatesAcrossFiles(files)
+
+	var paths []string
+	for _, path := range []string{"dir/a.xlsx", "dir/b.xlsx", "dir/c.xlsx"} {
+		if len(got[path]) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	if want := []string{"dir/a.xlsx", "dir/b.xlsx"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("FindDuplicatesAcrossFiles() files = %v, want %v", paths, want)
+	}
+	a := got["dir/a.xlsx"][0]
+	if a.Cell != "E2" || !strings.Contains(a.Message, "b.xlsx 4行目 (数量 3)") || !strings.Contains(a.Message, "合計数量 5") {
+		t.Errorf("a.xlsx finding = %+v", a)
+	}
+	b := got["dir/b.xlsx"][0]
+	if b.Cell != "E4" || !strings.Contains(b.Message, "a.xlsx 2行目 (数量 2)") {
+		t.Errorf("b.xlsx finding = %+v", b)
+	}
+}
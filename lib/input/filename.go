@@ -0,0 +1,133 @@
+package input
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"pncheck/lib/i18n"
+)
+
+// filenameDateLayout : ファイル名の先頭ブロックの日付の型
+const filenameDateLayout = "20060102"
+
+// Filename : 要求票のファイル名を命名規則
+//
+//	YYYYMMDD-製番-号機-発注区分[補足].xlsx
+//
+// に従って分解したもの。
+// 命名規則に合わない箇所は読み飛ばさずに Errs に記録する。
+type Filename struct {
+	Base      string    // ディレクトリと拡張子を除いたファイル名
+	DateText  string    // 1ブロック目の日付の文字列
+	Date      time.Time // DateTextを解釈した日付 (解釈できなければゼロ値)
+	Project   string    // 2ブロック目の製番
+	Serial    string    // 3ブロック目の号機
+	OrderCode string    // 4ブロック目の最初の文字 S:出庫, K:購入, G:外注
+	Suffix    string    // 4ブロック目の発注区分以降と5ブロック目以降
+	Errs      []error   // 命名規則に合わない箇所
+}
+
+// ParseFilename はファイルパスからファイル名を命名規則に従って分解します。
+func ParseFilename(filePath string) Filename {
+	base := filepath.Base(filePath)
+	// 拡張子のように見えても"-"を含むものはファイル名の一部とみなす
+	if ext := filepath.Ext(base); !strings.Contains(ext, "-") {
+		base = strings.TrimSuffix(base, ext)
+	}
+	fn := Filename{Base: base}
+
+	blocks := strings.Split(base, "-")
+	get := func(i int) string {
+		if i < len(blocks) {
+			return blocks[i]
+		}
+		return ""
+	}
+	fn.DateText, fn.Project, fn.Serial = get(0), get(1), get(2)
+	if rest := strings.Join(blocks[min(3, len(blocks)):], "-"); rest != "" {
+		_, size := utf8.DecodeRuneInString(rest)
+		fn.OrderCode = rest[:size]
+		fn.Suffix = strings.TrimPrefix(rest[size:], "-")
+	}
+
+	if len(blocks) < 4 {
+		fn.Errs = append(fn.Errs, i18n.Errorf(
+			"ファイル名 '%s' が命名規則 YYYYMMDD-製番-号機-発注区分 に従っていません", base))
+	}
+	if t, err := time.Parse(filenameDateLayout, fn.DateText); err != nil {
+		fn.Errs = append(fn.Errs, i18n.Errorf(
+			"ファイル名の日付 '%s' が YYYYMMDD の形式ではありません", fn.DateText))
+	} else {
+		fn.Date = t
+	}
+	if fn.Project == "" {
+		fn.Errs = append(fn.Errs, i18n.Errorf("ファイル名に製番がありません"))
+	}
+	if fn.Serial == "" {
+		fn.Errs = append(fn.Errs, i18n.Errorf("ファイル名に号機がありません"))
+	}
+	if len(blocks) > 3 && fn.orderType() == "" {
+		fn.Errs = append(fn.Errs, i18n.Errorf(
+			"ファイル名の発注区分 '%s' が S(出庫), K(購入), G(外注) のいずれでもありません。%sとして扱います",
+			blocks[3], 組部品))
+	}
+	return fn
+}
+
+// orderType : 発注区分の記号から発注区分を返す。記号が不正なら空文字
+func (fn Filename) orderType() OrderType {
+	switch fn.OrderCode {
+	case "S":
+		return 出庫
+	case "K":
+		return 購入
+	case "G":
+		return 外注
+	default:
+		return ""
+	}
+}
+
+// OrderType はファイル名から決まる発注区分を返します。
+// 記号が不正な場合や発注区分のブロックがない場合は組部品を返します。
+func (fn Filename) OrderType() OrderType {
+	if t := fn.orderType(); t != "" {
+		return t
+	}
+	return 組部品
+}
+
+// validateFilename はファイル名の命名規則と、
+// ファイル名の日付と要求年月日、ファイル名の号機と号機列が一致するか検証します。
+func validateFilename(fn Filename, sheet *Sheet) (warns []string) {
+	for _, err := range fn.Errs {
+		warns = append(warns, err.Error())
+	}
+
+	// ファイル名の日付と要求年月日
+	if !fn.Date.IsZero() && sheet.RequestDate != "" {
+		if fname := fn.Date.Format(DateLayout); fname != sheet.RequestDate {
+			warns = append(warns, i18n.T(
+				"ファイル名の日付 %s と要求年月日(%s!%s) %s が一致しません",
+				fname, headerSheetName, requestDateCell, sheet.RequestDate))
+		}
+	}
+
+	// ファイル名の号機と号機列
+	if fn.Serial != "" {
+		var rows []string
+		for _, o := range sheet.Orders {
+			if o.Serial != "" && o.Serial != fn.Serial {
+				rows = append(rows, i18n.T("%d行目 '%s'", o.Row, o.Serial))
+			}
+		}
+		if len(rows) > 0 {
+			warns = append(warns, i18n.T(
+				"ファイル名の号機 '%s' と号機列(%s)が一致しない行があります: %s",
+				fn.Serial, colSerial, strings.Join(rows, ", ")))
+		}
+	}
+	return
+}
@@ -0,0 +1,198 @@
+package input
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFilename(t *testing.T) {
+	tests := []struct {
+		name      string
+		filePath  string
+		want      Filename
+		wantErrs  int
+		wantOrder OrderType
+	}{
+		{
+			name:     "命名規則どおり",
+			filePath: "dir/20231027-123456789000-S001-K.xlsx",
+			want: Filename{
+				Base: "20231027-123456789000-S001-K", DateText: "20231027",
+				Date:    time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC),
+				Project: "123456789000", Serial: "S001", OrderCode: "K",
+			},
+			wantOrder: 購入,
+		},
+		{
+			name:     "補足付き",
+			filePath: "20231027-123456789000-S001-G-その3.xlsx",
+			want: Filename{
+				Base: "20231027-123456789000-S001-G-その3", DateText: "20231027",
+				Date:    time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC),
+				Project: "123456789000", Serial: "S001", OrderCode: "G", Suffix: "その3",
+			},
+			wantOrder: 外注,
+		},
+		{
+			name:     "発注区分なし",
+			filePath: "20251114-000080010742-TBP.xlsx",
+			want: Filename{
+				Base: "20251114-000080010742-TBP", DateText: "20251114",
+				Date:    time.Date(2025, 11, 14, 0, 0, 0, 0, time.UTC),
+				Project: "000080010742", Serial: "TBP",
+			},
+			wantErrs:  1,
+			wantOrder: 組部品,
+		},
+		{
+			name:     "発注区分の記号が不正で日付も不正",
+			filePath: "2023-123456789000-S001-X.xlsx",
+			want: Filename{
+				Base: "2023-123456789000-S001-X", DateText: "2023",
+				Project: "123456789000", Serial: "S001", OrderCode: "X",
+			},
+			wantErrs:  2,
+			wantOrder: 組部品,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseFilename(tt.filePath)
+			if len(got.Errs) != tt.wantErrs {
+				t.Errorf("Errs = %v, want %d errors", got.Errs, tt.wantErrs)
+			}
+			got.Errs = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFilename(%q) = %+v, want %+v", tt.filePath, got, tt.want)
+			}
+			if got.OrderType() != tt.wantOrder {
+				t.Errorf("OrderType() = %q, want %q", got.OrderType(), tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestValidateFilename(t *testing.T) {
+	fn := ParseFilename("20231027-123456789000-S001-K.xlsx")
+	sheet := &Sheet{
+		Header: Header{RequestDate: "2023/10/28"},
+		Orders: Orders{
+			{Row: 2, Serial: "S001"},
+			{Row: 3, Serial: ""}, // 空欄は比較しない
+			{Row: 5, Serial: "S002"},
+		},
+	}
+
+	warns := validateFilename(fn, sheet)
+	if len(warns) != 2 {
+		t.Fatalf("validateFilename() = %v, want 2 warnings", warns)
+	}
+	if !strings.Contains(warns[0], "2023/10/27") || !strings.Contains(warns[0], "2023/10/28") {
+		t.Errorf("日付の不一致が報告されていません: %s", warns[0])
+	}
+	if !strings.Contains(warns[1], "5行目 'S002'") || strings.Contains(warns[1], "2行目") {
+		t.Errorf("号機の不一致が行番号とともに報告されていません: %s", warns[1])
+	}
+}
+
+func TestParseFilename_OrderType(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		expected OrderType
+	}{
+		// --- Happy Path Cases (Valid Endings) ---
+		{"Valid S Ending", "path/to/222-some-file-S", 出庫},
+		{"Valid K Ending", "another/dir/222-222-data-K", 購入},
+		{"Valid G Ending", "just-a-name-G", 外注},
+		{"Valid S Ending - No Path", "file-tbd-20-S-2", 出庫},
+		{"Valid K Ending - No Path", "doc-123--K-1", 購入},
+		{"Valid G Ending - No Path", "2002-1234-tbd-G-その3", 外注},
+		{"Valid S Ending - Multiple Hyphens", "prefix-middle-suffix-S", 出庫},
+		{"Valid K Ending - Multiple Hyphens", "a-b-c-K", 購入},
+		{"Valid G Ending - Multiple Hyphens", "x-y-z-G", 外注},
+		{"Valid S Ending - Hyphen at Start", "202-231-tbd-S", 出庫}, // filepath.Base is "-file-S", split is ["", "file", "S"]
+
+		// --- Unhappy Path Cases (Invalid Endings / Default) ---
+		{"Invalid Ending - X", "file-X", 組部品},
+		{"Invalid Ending - ABC", "data-abc", 組部品},
+		{"Invalid Ending - Number", "report-123", 組部品},
+		{"Invalid Ending - Empty String after Hyphen", "file-", 組部品}, // Split results in ["file", ""], last is ""
+		{"Invalid Ending - Lowercase s", "file--s", 組部品},             // Case sensitive
+		{"Invalid Ending - Lowercase k", "file---k", 組部品},
+		{"Invalid Ending - Lowercase g", "file--g", 組部品},
+		{"Invalid Ending - Mixed Case", "file--S ", 組部品}, // Trailing space
+
+		// --- Edge Cases (No Hyphens, Empty, etc.) ---
+		{"No Hyphens - Just S", "S", 組部品}, // Last block is "S", but not after a hyphen
+		{"No Hyphens - Just K", "K", 組部品},
+		{"No Hyphens - Just G", "G", 組部品},
+		{"No Hyphens - Regular Filename", "myfile.txt", 組部品},       // Last block is "myfile.txt"
+		{"No Hyphens - Filename with Dots", "archive.tar.gz", 組部品}, // Last block is "archive.tar.gz"
+		{"Empty String Input", "", 組部品},                            // filepath.Base("") is ".", Split(".") is [".", ""], last is "" -> "組部品" (behavior might vary slightly by OS, but "." or "" are common)
+		{"Just a Hyphen", "-", 組部品},                                // filepath.Base("-") is "-", Split("-") is ["", ""], last is "" -> "組部品"
+		{"Hyphen at End", "file-S-", 組部品},                          // filepath.Base("file-S-") is "file-S-", Split is ["file", "S", ""], last is "" -> "組部品"
+		{"Hyphen at Start and End", "-file-S-", 組部品},               // filepath.Base is "-file-S-", Split is ["", "file", "S", ""], last is "" -> "組部品"
+
+		// --- Directory Path Cases ---
+		{"Directory Path - No File", "/path/to/dir/", 組部品}, // filepath.Base is "dir"
+		{"Root Directory Path", "/", 組部品},                  // filepath.Base is "/"
+		{"Current Directory", ".", 組部品},                    // filepath.Base is "."
+		{"Parent Directory", "..", 組部品},                    // filepath.Base is ".."
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := ParseFilename(tc.filePath).OrderType()
+			if actual != tc.expected {
+				t.Errorf("ParseFilename(%q).OrderType(): Expected %q, Got %q", tc.filePath, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseFilename_Serial(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		expected string
+	}{
+		{
+			name:     "valid file name",
+			filePath: "20231027-12345678-S001-K.xlsx",
+			expected: "S001",
+		},
+		{
+			name:     "short file name length 2",
+			filePath: "20231027-12345678.xlsx",
+			expected: "",
+		},
+		{
+			name:     "short file name length 3",
+			filePath: "20251114-000080010742-TBP.xlsx",
+			expected: "TBP",
+		},
+		{
+			name:     "empty file name",
+			filePath: "",
+			expected: "",
+		},
+		{
+			name:     "file name with different delimiters",
+			filePath: "20231027_12345678_S001_K.xlsx",
+			expected: "", // ハイフン区切りではないため
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actua := ParseFilename(tt.filePath).Serial
+			if actua != tt.expected {
+				t.Errorf("ParseFilename(%q).Serial = %q, want %q", tt.filePath, actua, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package input
+
+import (
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/i18n"
+)
+
+// FixedFileExt : 修正版の要求票に付ける拡張子
+const FixedFileExt = ".pncheck.xlsx"
+
+// Fixer : 修正版の要求票(<name>.pncheck.xlsx)を作る
+//
+// 元のファイルを開き直して書き換え、別名で保存するので元のファイルは変更しない。
+// -fix による修正と、PNSearchが修正した値の書き戻しで同じファイルを使う。
+// 書き換えた内容は Log に1件ずつ残す。
+type Fixer struct {
+	wb     *Workbook
+	Path   string      // 保存先のパス
+	Log    []string    // レポートに表示する修正内容
+	rowMap map[int]int // 並べ替える前の入力Ⅰの行番号 → 並べ替えた後の行番号
+}
+
+// OpenFixer は元の要求票を開き直し、修正版を作る準備をします。
+// 使い終わったら Close を呼ぶ必要があります。
+func OpenFixer(filePath string) (*Fixer, error) {
+	wb, err := OpenWorkbook(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Fixer{
+		wb:     wb,
+		Path:   strings.TrimSuffix(filePath, filepath.Ext(filePath)) + FixedFileExt,
+		rowMap: make(map[int]int),
+	}, nil
+}
+
+// Close は開き直したファイルを閉じます。
+func (fx *Fixer) Close() error {
+	return fx.wb.Close()
+}
+
+// logf : 修正内容を記録する
+func (fx *Fixer) logf(format string, a ...any) {
+	fx.Log = append(fx.Log, i18n.T(format, a...))
+}
+
+// Normalize は Normalize と NormalizeUnits で書き換えた値をセルに書き込みます。
+// 並べ替えより前に呼ぶ必要があります。
+func (fx *Fixer) Normalize(changes []Change) error {
+	for _, c := range changes {
+		if err := fx.wb.SetCellStr(c.Sheet, c.Cell, c.After); err != nil {
+			return i18n.Errorf("%s %s に書き込めません: %w", c.Sheet, c.Cell, err)
+		}
+		fx.logf("正規化: %s", c)
+	}
+	return nil
+}
+
+// ClearHiddenColumns は入力Ⅰの隠し列に入力されている値を削除します。
+// 対象のセルは hidden-column ルールと同じです。
+func (fx *Fixer) ClearHiddenColumns() error {
+	f := fx.wb.File
+	rows := sheetRows(f, orderSheetName)
+	for _, col := range hiddenColumns {
+		for _, cell := range filledCells(rows, col) {
+			before := getCellValue(f, orderSheetName, cell)
+			if err := f.SetCellFormula(orderSheetName, cell, ""); err != nil {
+				return i18n.Errorf("%s %s を削除できません: %w", orderSheetName, cell, err)
+			}
+			if err := f.SetCellValue(orderSheetName, cell, nil); err != nil {
+				return i18n.Errorf("%s %s を削除できません: %w", orderSheetName, cell, err)
+			}
+			fx.logf("隠し列の入力を削除: %s %s %s", orderSheetName, cell, strconv.Quote(before))
+		}
+	}
+	return nil
+}
+
+// cellContent : 並べ替えで移すセルの値
+type cellContent struct {
+	typ   excelize.CellType
+	value string
+}
+
+// SortOrders は入力Ⅰの明細を要望納期と品番の順(sort-order ルールと同じ順)に並べ替えます。
+//
+// 印刷シートは入力Ⅰの行を参照しているので、行を挿入・削除せずにセルの値だけを入れ替える。
+// 書式は行に残る。組部品は並べ替えない。
+func (fx *Fixer) SortOrders(sheet *Sheet) error {
+	if isAssyProject(sheet.ProjectID) {
+		return nil
+	}
+	sorted := slices.Clone(sheet.Orders)
+	slices.SortStableFunc(sorted, compareOrders)
+	if slices.EqualFunc(sorted, sheet.Orders, func(a, b Order) bool { return a.Row == b.Row }) {
+		return nil
+	}
+
+	f := fx.wb.File
+	last, _ := excelize.ColumnNameToNumber(colUnitPrice)
+	for _, row := range sheetRows(f, orderSheetName) {
+		last = max(last, len(row))
+	}
+
+	// 書き込む前にすべての行を読み込む
+	contents := make(map[int][]cellContent)
+	for _, o := range sheet.Orders {
+		cells := make([]cellContent, last)
+		for c := range cells {
+			cell, _ := excelize.CoordinatesToCellName(c+1, o.Row)
+			if formula, _ := f.GetCellFormula(orderSheetName, cell); formula != "" {
+				return i18n.Errorf("明細の行に数式があるため並べ替えられません: %s %s", orderSheetName, cell)
+			}
+			typ, _ := f.GetCellType(orderSheetName, cell)
+			value, _ := f.GetCellValue(orderSheetName, cell)
+			cells[c] = cellContent{typ, value}
+		}
+		contents[o.Row] = cells
+	}
+
+	for i, o := range sorted {
+		dst := sheet.Orders[i].Row
+		fx.rowMap[o.Row] = dst
+		if o.Row == dst {
+			continue
+		}
+		for c, content := range contents[o.Row] {
+			cell, _ := excelize.CoordinatesToCellName(c+1, dst)
+			if err := writeCellContent(f, orderSheetName, cell, content); err != nil {
+				return i18n.Errorf("%s %s に書き込めません: %w", orderSheetName, cell, err)
+			}
+		}
+		fx.logf("並べ替え: %s %d行目 → %d行目 (%s %s)", orderSheetName, o.Row, dst, o.Deadline, o.Pid)
+	}
+	return nil
+}
+
+// writeCellContent : 読み込んだときの型でセルに値を書き込む
+func writeCellContent(f *excelize.File, sheetName, cell string, c cellContent) error {
+	if c.value == "" {
+		return f.SetCellValue(sheetName, cell, nil)
+	}
+	switch c.typ {
+	case excelize.CellTypeBool:
+		return f.SetCellBool(sheetName, cell, c.value == "1" || strings.EqualFold(c.value, "TRUE"))
+	case excelize.CellTypeUnset, excelize.CellTypeNumber, excelize.CellTypeDate:
+		// 型の指定がないセルは数値として保存されている
+		if v, err := strconv.ParseFloat(c.value, 64); err == nil {
+			return f.SetCellFloat(sheetName, cell, v, -1, 64)
+		}
+	}
+	return f.SetCellStr(sheetName, cell, c.value)
+}
+
+// overrideTargets : PNSearchが Overridable で修正する明細の項目
+var overrideTargets = []normalizeTarget{
+	{"品名", colName, func(o *Order) *string { return &o.Name }},
+	{"型式", colType, func(o *Order) *string { return &o.Type }},
+	{"単位", colUnit, func(o *Order) *string { return &o.Unit }},
+}
+
+// highlightColor : PNSearchが修正したセルの背景色
+const highlightColor = "FFFF00"
+
+// ApplyServerSheet はPNSearchが修正して返したserverの品名、型式、単位のうち、
+// localと異なる値を processOrderRow が読み込むセルに書き込み、背景色を付けます。
+//
+// PNSearchは明細を送った順に返すので、同じ位置の明細を比較する。
+// 並べ替えた後に呼んでも、並べ替える前の行の明細に書き込む。
+func (fx *Fixer) ApplyServerSheet(local, server *Sheet) error {
+	if len(server.Orders) == 0 {
+		return nil // 明細を返さなかった場合は修正がないものとする
+	}
+	if len(server.Orders) != len(local.Orders) {
+		return i18n.Errorf("PNSearchが返した明細の数(%d)が要求票(%d)と異なるため、修正を反映できません",
+			len(server.Orders), len(local.Orders))
+	}
+	for i := range local.Orders {
+		o, s := &local.Orders[i], &server.Orders[i]
+		for _, t := range overrideTargets {
+			before, after := *t.value(o), *t.value(s)
+			if after == "" || after == before {
+				continue
+			}
+			cell := t.col + strconv.Itoa(fx.row(o.Row))
+			if err := fx.wb.SetCellStr(orderSheetName, cell, after); err != nil {
+				return i18n.Errorf("%s %s に書き込めません: %w", orderSheetName, cell, err)
+			}
+			if err := fx.highlight(orderSheetName, cell); err != nil {
+				return i18n.Errorf("%s %s に背景色を付けられません: %w", orderSheetName, cell, err)
+			}
+			fx.logf("PNSearchの修正: %s", Change{orderSheetName, cell, t.field, before, after})
+		}
+	}
+	return nil
+}
+
+// row : 並べ替えた後の入力Ⅰの行番号
+func (fx *Fixer) row(r int) int {
+	if dst, ok := fx.rowMap[r]; ok {
+		return dst
+	}
+	return r
+}
+
+// highlight : セルの書式を保ったまま背景色を付ける
+func (fx *Fixer) highlight(sheetName, cell string) error {
+	id, err := fx.wb.GetCellStyle(sheetName, cell)
+	if err != nil {
+		return err
+	}
+	style, err := fx.wb.GetStyle(id)
+	if err != nil {
+		return err
+	}
+	style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{highlightColor}}
+	id, err = fx.wb.NewStyle(style)
+	if err != nil {
+		return err
+	}
+	return fx.wb.SetCellStyle(sheetName, cell, cell, id)
+}
+
+// Save は入力Ⅰをアクティブにして修正版を保存します。
+func (fx *Fixer) Save() error {
+	idx, err := fx.wb.GetSheetIndex(orderSheetName)
+	if err != nil || idx < 0 {
+		return i18n.Errorf("入力Iシートが見つかりません: %w", err)
+	}
+	if idx != fx.wb.GetActiveSheetIndex() {
+		fx.wb.SetActiveSheet(idx)
+		fx.logf("%sをアクティブにしました", orderSheetName)
+	}
+	if err := fx.wb.SaveAs(fx.Path); err != nil {
+		return i18n.Errorf("修正版を保存できません '%s': %w", fx.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestFixer(t *testing.T) {
+	path := createTestExcelFile(t, "testdata_fix", "20231027-fix-K.xlsx", func(f *excelize.File) {
+		f.SetCellValue(headerSheetName, projectIDCell, "123456789000")
+		f.SetCellValue(printSheetNameDefault, versionCell, "M-701-04")
+		for row, v := range map[string][]any{
+			"2": {"PN-10", "2023/11/15", 3},
+			"3": {"PN-9", "2023/11/15", 2},
+			"4": {"PN-1", "2023/11/01", 1},
+		} {
+			f.SetCellValue(orderSheetName, colPid+row, v[0])
+			f.SetCellValue(orderSheetName, colName+row, "部品")
+			f.SetCellValue(orderSheetName, colDeadlineO+row, v[1])
+			f.SetCellValue(orderSheetName, colQuantity+row, v[2])
+		}
+		f.SetCellValue(orderSheetName, "B3", "stray")   // 隠し列の入力
+		f.SetColVisible(orderSheetName, colName, false) // 利用者が隠した品名の列は削除しない
+		f.SetActiveSheet(0)                             // 入力Ⅱ
+	})
+	wb, err := OpenWorkbook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet, err := wb.ReadSheet()
+	wb.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fx, err := OpenFixer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fx.Close()
+	changes := []Change{{Sheet: orderSheetName, Cell: "F2", Field: "品名", Before: "部品", After: "部品X"}}
+	if err := fx.Normalize(changes); err != nil {
+		t.Fatal(err)
+	}
+	if err := fx.ClearHiddenColumns(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fx.SortOrders(&sheet); err != nil {
+		t.Fatal(err)
+	}
+	if err := fx.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := filepath.Join(filepath.Dir(path), "20231027-fix-K.pncheck.xlsx"); fx.Path != want {
+		t.Errorf("Fixer.Path = %q, want %q", fx.Path, want)
+	}
+	wantLog := []string{
+		`正規化: 入力Ⅰ F2 品名: "部品" → "部品X"`,
+		`隠し列の入力を削除: 入力Ⅰ B3 "stray"`,
+		"並べ替え: 入力Ⅰ 4行目 → 2行目 (2023/11/01 PN-1)",
+		"並べ替え: 入力Ⅰ 2行目 → 4行目 (2023/11/15 PN-10)", // 行が変わらない3行目は記録しない
+		"入力Ⅰをアクティブにしました",
+	}
+	if !reflect.DeepEqual(fx.Log, wantLog) {
+		t.Errorf("Fixer.Log = %q, want %q", fx.Log, wantLog)
+	}
+
+	// 元のファイルは変更しない
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Error("元のファイルが変更されました")
+	}
+
+	fixed, err := OpenWorkbook(fx.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fixed.Close()
+	got, err := fixed.ReadSheet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pids, names []string
+	for _, o := range got.Orders {
+		pids = append(pids, o.Pid)
+		names = append(names, o.Name)
+	}
+	if want := []string{"PN-1", "PN-9", "PN-10"}; !reflect.DeepEqual(pids, want) {
+		t.Errorf("修正版の品番 = %v, want %v", pids, want)
+	}
+	if want := []string{"部品", "部品", "部品X"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("修正版の品名 = %v, want %v", names, want)
+	}
+	if got.Orders[0].Quantity != 1 {
+		t.Errorf("修正版の数量 = %g, want 1", got.Orders[0].Quantity)
+	}
+	if findings := validateHiddenColumns(fixed.File); findings != nil {
+		t.Errorf("修正版の隠し列に入力が残っています: %v", findings)
+	}
+	if name := fixed.GetSheetName(fixed.GetActiveSheetIndex()); name != orderSheetName {
+		t.Errorf("修正版のアクティブシート = %s, want %s", name, orderSheetName)
+	}
+}
+
+func TestFixer_ApplyServerSheet(t *testing.T) {
+	path := createTestExcelFile(t, "testdata_fix_server", "20231027-server-K.xlsx", func(f *excelize.File) {
+		f.SetCellValue(orderSheetName, colName+"2", "ボルト")
+		f.SetCellValue(orderSheetName, colName+"3", "なっと")
+	})
+	local := &Sheet{Orders: Orders{
+		{Pid: "PN-2", Name: "ボルト", Unit: "個", Deadline: "2023/11/15", Row: 2},
+		{Pid: "PN-1", Name: "なっと", Unit: "個", Deadline: "2023/11/15", Row: 3},
+	}}
+	server := &Sheet{Orders: Orders{
+		{Pid: "PN-2", Name: "ボルト", Unit: "個"},
+		{Pid: "PN-1", Name: "ナット", Type: "M6", Unit: ""}, // 空欄は修正とみなさない
+	}}
+
+	fx, err := OpenFixer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fx.Close()
+	fx.rowMap = map[int]int{2: 3, 3: 2} // 並べ替えた後
+	if err := fx.ApplyServerSheet(local, server); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`PNSearchの修正: 入力Ⅰ F2 品名: "なっと" → "ナット"`,
+		`PNSearchの修正: 入力Ⅰ G2 型式: "" → "M6"`,
+	}
+	if !reflect.DeepEqual(fx.Log, want) {
+		t.Errorf("Fixer.Log = %q, want %q", fx.Log, want)
+	}
+	if v, _ := fx.wb.GetCellValue(orderSheetName, "F2"); v != "ナット" {
+		t.Errorf("F2 = %q, want ナット", v)
+	}
+	id, _ := fx.wb.GetCellStyle(orderSheetName, "F2")
+	style, err := fx.wb.GetStyle(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(style.Fill.Color, []string{highlightColor}) {
+		t.Errorf("F2の背景色 = %v, want %s", style.Fill.Color, highlightColor)
+	}
+
+	server.Orders = server.Orders[:1]
+	if err := fx.ApplyServerSheet(local, server); err == nil {
+		t.Error("明細の数が異なるのにエラーが返されませんでした")
+	}
+}
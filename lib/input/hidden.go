@@ -0,0 +1,144 @@
+package input
+
+import (
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/i18n"
+)
+
+func init() {
+	Register(static(
+		ruleFunc{
+			id:          "hidden-column",
+			description: "入力Ⅰの隠し列に入力がないこと",
+			severity:    SeverityFatal,
+			check: func(wb *Workbook, _ *Sheet) []Finding {
+				return validateHiddenColumns(wb.File)
+			},
+		},
+		ruleFunc{
+			id:          "hidden-column-layout",
+			description: "入力Ⅰの隠し列がテンプレートと同じであること",
+			severity:    SeverityWarning,
+			check: func(wb *Workbook, _ *Sheet) []Finding {
+				return validateColumnLayout(wb.File)
+			},
+		},
+	))
+}
+
+// hiddenColumns : テンプレートの隠し列一覧
+var hiddenColumns = []string{
+	"B", "C", "D", "H", "L", "P", "Q", "R", "S", "T", "U", "V", "W", "X", "Z",
+	"AA", "AB", "AC", "AD", "AE", "AF", "AG", "AH", "AI", "AK", "AL", "AM", "AN",
+	"AO", "AP", "AQ", "AR", "AS", "AT", "AU", "AV", "AW", "AX", "AY", "AZ", "BA",
+	"BB", "BC", "BD",
+}
+
+// columnLayout : 入力Ⅰの列の表示状態をテンプレートと比較した結果
+type columnLayout struct {
+	unhidden  []string // テンプレートでは隠し列なのに表示されている列
+	newHidden []string // テンプレートでは表示されているのに隠れている列
+}
+
+// readColumnLayout はA列から予定単価の列(またはデータのある最後の列)まで、
+// 列の表示状態をブックから読み込んでテンプレートの隠し列と比較します。
+func readColumnLayout(f *excelize.File, rows [][]string) (layout columnLayout) {
+	last, _ := excelize.ColumnNameToNumber(colUnitPrice)
+	for _, row := range rows {
+		last = max(last, len(row))
+	}
+	for n := 1; n <= last; n++ {
+		col, _ := excelize.ColumnNumberToName(n)
+		visible, err := f.GetColVisible(orderSheetName, col)
+		if err != nil {
+			slog.Warn(i18n.T("列の表示状態を読み込めません"), slog.String("col", col), slog.String("error", err.Error()))
+			visible = true
+		}
+		expected := slices.Contains(hiddenColumns, col)
+		switch {
+		case expected && visible:
+			layout.unhidden = append(layout.unhidden, col)
+		case !expected && !visible:
+			layout.newHidden = append(layout.newHidden, col)
+		}
+	}
+	return
+}
+
+// sheetRows : シートの使用範囲のすべての値を読み込む
+func sheetRows(f *excelize.File, sheetName string) [][]string {
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		slog.Warn(i18n.T("シート '%s' を読み込めません", sheetName), slog.String("error", err.Error()))
+		return nil
+	}
+	return rows
+}
+
+// filledCells : 指定列のordersStartRowから使用範囲の最終行までで、値のあるセルを返す
+func filledCells(rows [][]string, col string) (cells []string) {
+	n, err := excelize.ColumnNameToNumber(col)
+	if err != nil {
+		return
+	}
+	for r := ordersStartRow - 1; r < len(rows); r++ {
+		if n <= len(rows[r]) && strings.TrimSpace(rows[r][n-1]) != "" {
+			cells = append(cells, col+strconv.Itoa(r+1))
+		}
+	}
+	return
+}
+
+// IsEmptyColumn : 指定列のordersStartRowから使用範囲の最終行までがすべて空文字かどうかを返す
+func IsEmptyColumn(f *excelize.File, sheetName, col string) bool {
+	return len(filledCells(sheetRows(f, sheetName), col)) == 0
+}
+
+// validateHiddenColumns は入力Ⅰの隠し列に入力がないか検証し、
+// 入力のある列ごとに値のあるセルをすべて示すFindingを返します。
+// 対象はテンプレートの隠し列だけで、利用者が隠した列は hidden-column-layout で知らせます。
+func validateHiddenColumns(f *excelize.File) (findings []Finding) {
+	rows := sheetRows(f, orderSheetName)
+	for _, col := range hiddenColumns {
+		cells := filledCells(rows, col)
+		if len(cells) == 0 {
+			continue
+		}
+		_, row, _ := excelize.SplitCellName(cells[0])
+		findings = append(findings, Finding{
+			Sheet:   orderSheetName,
+			Row:     row,
+			Cell:    cells[0],
+			Message: i18n.T("隠し列が空である確認: 隠し列 %s に入力があります: %s", col, strings.Join(cells, ", ")),
+		})
+	}
+	return
+}
+
+// validateColumnLayout は入力Ⅰの列の表示状態がテンプレートと異なれば、
+// 表示された隠し列と新たに隠された列を1列ずつFindingとして返します。
+// 抑制設定で列ごとに抑制できるよう、Cellには列の1行目を設定します。
+func validateColumnLayout(f *excelize.File) (findings []Finding) {
+	layout := readColumnLayout(f, sheetRows(f, orderSheetName))
+	for _, col := range layout.unhidden {
+		findings = append(findings, Finding{
+			Sheet:   orderSheetName,
+			Cell:    col + "1",
+			Message: i18n.T("入力Ⅰでテンプレートの隠し列 %s が表示されています", col),
+		})
+	}
+	for _, col := range layout.newHidden {
+		findings = append(findings, Finding{
+			Sheet:   orderSheetName,
+			Cell:    col + "1",
+			Message: i18n.T("入力Ⅰでテンプレートにない列 %s が隠されています", col),
+		})
+	}
+	return
+}
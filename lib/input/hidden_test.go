@@ -0,0 +1,243 @@
+package input
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestIsEmptyColumn(t *testing.T) {
+	t.Parallel()
+
+	// ヘルパー: テスト用のインメモリExcelファイルを生成
+	setupFile := func(t *testing.T, data map[string]string) *excelize.File {
+		t.Helper()
+		f := excelize.NewFile()
+		_, err := f.NewSheet(orderSheetName)
+		if err != nil {
+			t.Fatalf("Failed to create sheet: %v", err)
+		}
+		for axis, val := range data {
+			if err := f.SetCellValue(orderSheetName, axis, val); err != nil {
+				t.Fatalf("Failed to set cell value: %v", err)
+			}
+		}
+		return f
+	}
+
+	tests := []struct {
+		name      string
+		excelData map[string]string
+		col       string
+		want      bool
+	}{
+		{
+			name:      "指定範囲がすべて空文字の場合_trueを返す",
+			excelData: map[string]string{}, // データなし
+			col:       "A",
+			want:      true,
+		},
+		{
+			name: "指定範囲外(開始行未満)に値があっても影響せず_trueを返す",
+			excelData: map[string]string{
+				"A1": "header", // ordersStartRow(2) 未満の1行目
+			},
+			col:  "A",
+			want: true,
+		},
+		{
+			name: "101行目より後でも使用範囲内に値がある場合_falseを返す",
+			excelData: map[string]string{
+				"A150": "value", // テンプレートの101行目を超えた150行目
+			},
+			col:  "A",
+			want: false,
+		},
+		{
+			name: "指定範囲の途中に値がある場合_falseを返す",
+			excelData: map[string]string{
+				"A3": "dirty", // ordersStartRow(2) 以降
+			},
+			col:  "A",
+			want: false,
+		},
+		{
+			name: "指定範囲の境界(開始行)に値がある場合_falseを返す",
+			excelData: map[string]string{
+				"A2": "start_edge",
+			},
+			col:  "A",
+			want: false,
+		},
+		{
+			name: "指定範囲の境界(最終行)に値がある場合_falseを返す",
+			excelData: map[string]string{
+				"A101": "end_edge",
+			},
+			col:  "A",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // Go 1.22未満のループ変数の参照対策
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := setupFile(t, tt.excelData)
+			defer f.Close()
+
+			got := IsEmptyColumn(f, orderSheetName, tt.col)
+			if got != tt.want {
+				t.Errorf("IsEmptyColumn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateHiddenColumns(t *testing.T) {
+	t.Parallel()
+
+	// ヘルパー: テスト用の一時Excelファイルを作成してパスを返す
+	createTempExcel := func(t *testing.T, data map[string]string) string {
+		t.Helper()
+		f := excelize.NewFile()
+		_, err := f.NewSheet(orderSheetName)
+		if err != nil {
+			t.Fatalf("Failed to create sheet: %v", err)
+		}
+
+		// テストデータの書き込み
+		for axis, val := range data {
+			if err := f.SetCellValue(orderSheetName, axis, val); err != nil {
+				t.Fatalf("Failed to set cell value: %v", err)
+			}
+		}
+
+		// 一時ディレクトリに保存
+		tmpDir := t.TempDir()
+		filePath := filepath.Join(tmpDir, "test_order.xlsx")
+		if err := f.SaveAs(filePath); err != nil {
+			t.Fatalf("Failed to save temp file: %v", err)
+		}
+		return filePath
+	}
+
+	tests := []struct {
+		name      string
+		setupFile func(t *testing.T) string
+		want      []string // Findingのメッセージ
+	}{
+		{
+			name: "隠し列(B, D)に一切入力がない場合_指摘なし",
+			setupFile: func(t *testing.T) string {
+				return createTempExcel(t, map[string]string{
+					"A1": "HeaderA", "B1": "HiddenHeaderB", "C1": "HeaderC", "D1": "HiddenHeaderD",
+					"A2": "data", "E2": "data", // 隠し列(B, D)のデータ行(2行目以降)は空
+				})
+			},
+		},
+		{
+			name: "隠し列Bに入力がある場合_セルを示す",
+			setupFile: func(t *testing.T) string {
+				return createTempExcel(t, map[string]string{
+					"A2": "data",
+					"B2": "invalid_data", // 隠し列Bに入力あり
+				})
+			},
+			want: []string{"隠し列が空である確認: 隠し列 B に入力があります: B2"},
+		},
+		{
+			name: "複数の隠し列(B, C, D)に入力がある場合_列ごとにすべてのセルを示す",
+			setupFile: func(t *testing.T) string {
+				return createTempExcel(t, map[string]string{
+					"A2":   "data",
+					"B2":   "invalid_data1", // 隠し列Bに入力あり
+					"C2":   "invalid_data2", // 隠し列Cに入力あり
+					"D3":   "invalid_data3", // 隠し列Dに入力あり
+					"D120": "invalid_data4", // 101行目より後の入力
+				})
+			},
+			want: []string{
+				"隠し列が空である確認: 隠し列 B に入力があります: B2",
+				"隠し列が空である確認: 隠し列 C に入力があります: C2",
+				"隠し列が空である確認: 隠し列 D に入力があります: D3, D120",
+			},
+		},
+		{
+			name: "利用者が隠した列Eに入力がある場合_指摘なし",
+			setupFile: func(t *testing.T) string {
+				f := excelize.NewFile()
+				f.NewSheet(orderSheetName)
+				f.SetCellValue(orderSheetName, "E5", "hidden")
+				f.SetColVisible(orderSheetName, "E", false)
+				path := filepath.Join(t.TempDir(), "test_order.xlsx")
+				if err := f.SaveAs(path); err != nil {
+					t.Fatal(err)
+				}
+				return path
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			wb, err := OpenWorkbook(tt.setupFile(t))
+			if err != nil {
+				t.Fatalf("OpenWorkbook() error = %v", err)
+			}
+			defer wb.Close()
+
+			var got []string
+			for _, f := range validateHiddenColumns(wb.File) {
+				got = append(got, f.Message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateHiddenColumns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateColumnLayout(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+	f.NewSheet(orderSheetName)
+	for _, col := range hiddenColumns {
+		f.SetColVisible(orderSheetName, col, false)
+	}
+	if got := validateColumnLayout(f); got != nil {
+		t.Errorf("テンプレートどおりの隠し列で指摘されました: %v", got)
+	}
+
+	f.SetColVisible(orderSheetName, "C", true)
+	f.SetColVisible(orderSheetName, "AX", true)
+	f.SetColVisible(orderSheetName, "G", false)
+	var got []string
+	for _, fd := range validateColumnLayout(f) {
+		got = append(got, fd.Cell+" "+fd.Message)
+	}
+	want := []string{
+		"C1 入力Ⅰでテンプレートの隠し列 C が表示されています",
+		"AX1 入力Ⅰでテンプレートの隠し列 AX が表示されています",
+		"G1 入力Ⅰでテンプレートにない列 G が隠されています",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("validateColumnLayout() = %v, want %v", got, want)
+	}
+
+	// 意図して表示した列だけを抑制設定で抑制できる
+	findings := validateColumnLayout(f)
+	for i := range findings {
+		findings[i].RuleID = "hidden-column-layout"
+	}
+	ignores := Ignores{{Rule: "hidden-column-layout", Cell: "AX", Reason: "改造版のテンプレート"}}
+	kept, suppressed := ignores.Apply(findings, "20250401-123456789000-001-K.xlsx", "123456789000", time.Now())
+	if len(kept) != 2 || len(suppressed) != 1 || suppressed[0].Cell != "AX1" {
+		t.Errorf("Apply() kept = %v, suppressed = %v, want AX suppressed", kept, suppressed)
+	}
+}
@@ -0,0 +1,165 @@
+package input
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pncheck/lib/i18n"
+)
+
+// IgnoreFileName : 要求票と同じディレクトリから探す抑制設定ファイル名
+const IgnoreFileName = ".pncheckignore"
+
+// Ignore : 承知済みの問題を抑制する設定1件
+//
+// Rule, File, Project は * や ? を使ったパターンで指定できる。
+// 空欄の項目はすべてに一致する。
+type Ignore struct {
+	Rule    string `json:"rule"`              // ルールID (例: hidden-column)
+	File    string `json:"file,omitempty"`    // ファイル名 (例: 2025*-K*.xlsx)
+	Project string `json:"project,omitempty"` // 製番
+	Cell    string `json:"cell,omitempty"`    // セル番地 "AK13", 列 "AK", シート名付き "入力Ⅰ!AK13"
+	Expires string `json:"expires,omitempty"` // この日まで抑制する (YYYY/MM/DD)。空欄なら無期限
+	Reason  string `json:"reason"`            // 抑制する理由
+}
+
+// Ignores : 抑制設定ファイルの内容
+type Ignores []Ignore
+
+// Suppressed : 抑制設定によってステータスから除外したFinding
+type Suppressed struct {
+	Finding
+	Ignore Ignore
+}
+
+// String : レポート表示用の文字列
+func (s Suppressed) String() string {
+	if s.Ignore.Expires == "" {
+		return i18n.T("%s (理由: %s)", s.Finding, s.Ignore.Reason)
+	}
+	return i18n.T("%s (理由: %s, 期限: %s)", s.Finding, s.Ignore.Reason, s.Ignore.Expires)
+}
+
+// LoadIgnores はディレクトリの抑制設定ファイルを読み込みます。
+// ファイルがなければ空の設定を返します。
+func LoadIgnores(dir string) (Ignores, error) {
+	p := filepath.Join(dir, IgnoreFileName)
+	b, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, i18n.Errorf("抑制設定ファイルを読み込めません '%s': %w", p, err)
+	}
+	var ignores Ignores
+	if err := json.Unmarshal(b, &ignores); err != nil {
+		return nil, i18n.Errorf("抑制設定ファイルのJSON解析に失敗しました '%s': %w", p, err)
+	}
+	for i, ig := range ignores {
+		if err := ig.validate(); err != nil {
+			return nil, i18n.Errorf("抑制設定ファイルの%d件目が不正です '%s': %w", i+1, p, err)
+		}
+	}
+	return ignores, nil
+}
+
+// validate : 抑制設定の必須項目と書式を確認する
+func (ig Ignore) validate() error {
+	if ig.Rule == "" {
+		return i18n.Errorf("rule がありません")
+	}
+	if ig.Reason == "" {
+		return i18n.Errorf("reason がありません")
+	}
+	for _, pattern := range []string{ig.Rule, ig.File, ig.Project} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return i18n.Errorf("パターン '%s' が不正です: %w", pattern, err)
+		}
+	}
+	if ig.Expires != "" {
+		if _, err := time.Parse(DateLayout, ig.Expires); err != nil {
+			return i18n.Errorf("expires '%s' が %s の形式ではありません", ig.Expires, DateLayout)
+		}
+	}
+	return nil
+}
+
+// Apply はFindingを抑制設定に一致するものとしないものに分けます。
+//
+// 期限切れの設定は適用せず、一致したFindingのメッセージに期限切れであることを添えます。
+func (ignores Ignores) Apply(findings []Finding, filePath, projectID string, today time.Time) (kept []Finding, suppressed []Suppressed) {
+	name := filepath.Base(filePath)
+	for _, f := range findings {
+		var expired *Ignore
+		matched := false
+		for i, ig := range ignores {
+			if !ig.match(f, name, projectID) {
+				continue
+			}
+			if ig.expired(today) {
+				expired = &ignores[i]
+				continue
+			}
+			suppressed = append(suppressed, Suppressed{Finding: f, Ignore: ig})
+			matched = true
+			break
+		}
+		if matched {
+			continue
+		}
+		if expired != nil {
+			f.Message += i18n.T(" (抑制設定の期限 %s が過ぎています)", expired.Expires)
+		}
+		kept = append(kept, f)
+	}
+	return
+}
+
+// match : Findingとファイル名、製番が設定に一致するか
+func (ig Ignore) match(f Finding, name, projectID string) bool {
+	return matchPattern(ig.Rule, f.RuleID) &&
+		matchPattern(ig.File, name) &&
+		matchPattern(ig.Project, projectID) &&
+		ig.matchCell(f)
+}
+
+// matchCell : セルの指定がFindingの場所に一致するか
+// 列だけの指定はその列のすべてのセルに一致する
+func (ig Ignore) matchCell(f Finding) bool {
+	if ig.Cell == "" {
+		return true
+	}
+	cell := ig.Cell
+	if sheet, c, ok := strings.Cut(cell, "!"); ok {
+		if sheet != f.Sheet {
+			return false
+		}
+		cell = c
+	}
+	if strings.EqualFold(cell, f.Cell) {
+		return true
+	}
+	col := strings.TrimRight(f.Cell, "0123456789")
+	return f.Cell != "" && strings.EqualFold(cell, col)
+}
+
+// expired : todayが有効期限を過ぎているか
+func (ig Ignore) expired(today time.Time) bool {
+	// YYYY/MM/DD の文字列は辞書順が日付順と一致する
+	return ig.Expires != "" && today.Format(DateLayout) > ig.Expires
+}
+
+// matchPattern : 空のパターンはすべてに一致する
+func matchPattern(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
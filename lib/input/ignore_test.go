@@ -0,0 +1,98 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIgnores_Apply(t *testing.T) {
+	today := time.Date(2025, time.April, 1, 9, 0, 0, 0, time.Local)
+	filePath := filepath.Join("dir", "20250401-123456789000-001-K.xlsx")
+	finding := Finding{RuleID: "hidden-column", Sheet: orderSheetName, Cell: "AK13", Message: "隠し列に入力があります"}
+
+	tests := []struct {
+		name           string
+		ignore         Ignore
+		wantSuppressed bool
+		wantExpired    bool
+	}{
+		{"ルールIDのみ", Ignore{Rule: "hidden-column", Reason: "r"}, true, false},
+		{"ルールIDのパターン", Ignore{Rule: "hidden-*", Reason: "r"}, true, false},
+		{"別のルールID", Ignore{Rule: "sort-order", Reason: "r"}, false, false},
+		{"ファイル名のパターン", Ignore{Rule: "hidden-column", File: "*-K.xlsx", Reason: "r"}, true, false},
+		{"ファイル名が一致しない", Ignore{Rule: "hidden-column", File: "*-G.xlsx", Reason: "r"}, false, false},
+		{"製番", Ignore{Rule: "hidden-column", Project: "123456789000", Reason: "r"}, true, false},
+		{"製番が一致しない", Ignore{Rule: "hidden-column", Project: "999999999999", Reason: "r"}, false, false},
+		{"セル番地", Ignore{Rule: "hidden-column", Cell: "AK13", Reason: "r"}, true, false},
+		{"列", Ignore{Rule: "hidden-column", Cell: "AK", Reason: "r"}, true, false},
+		{"シート名付き", Ignore{Rule: "hidden-column", Cell: "入力Ⅰ!AK13", Reason: "r"}, true, false},
+		{"別のシート", Ignore{Rule: "hidden-column", Cell: "入力Ⅱ!AK13", Reason: "r"}, false, false},
+		{"別のセル", Ignore{Rule: "hidden-column", Cell: "AK14", Reason: "r"}, false, false},
+		{"期限当日", Ignore{Rule: "hidden-column", Expires: "2025/04/01", Reason: "r"}, true, false},
+		{"期限切れ", Ignore{Rule: "hidden-column", Expires: "2025/03/31", Reason: "r"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, suppressed := Ignores{tt.ignore}.Apply([]Finding{finding}, filePath, "123456789000", today)
+			if got := len(suppressed) == 1; got != tt.wantSuppressed {
+				t.Fatalf("Apply() suppressed = %v, kept = %v", suppressed, kept)
+			}
+			if tt.wantSuppressed {
+				return
+			}
+			if len(kept) != 1 {
+				t.Fatalf("Apply() kept = %v, want 1 finding", kept)
+			}
+			if got := strings.Contains(kept[0].Message, "期限"); got != tt.wantExpired {
+				t.Errorf("期限切れの表示 = %v, want %v: %s", got, tt.wantExpired, kept[0].Message)
+			}
+		})
+	}
+}
+
+func TestLoadIgnores(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	t.Run("ファイルがなければ空", func(t *testing.T) {
+		ignores, err := LoadIgnores(t.TempDir())
+		if err != nil || ignores != nil {
+			t.Errorf("LoadIgnores() = %v, %v", ignores, err)
+		}
+	})
+
+	t.Run("読み込み", func(t *testing.T) {
+		dir := write(t, `[{"rule": "hidden-column", "cell": "AK", "expires": "2026/12/31", "reason": "テンプレートの改造版で使用"}]`)
+		ignores, err := LoadIgnores(dir)
+		if err != nil {
+			t.Fatalf("LoadIgnores() error = %v", err)
+		}
+		if len(ignores) != 1 || ignores[0].Cell != "AK" {
+			t.Errorf("LoadIgnores() = %v", ignores)
+		}
+	})
+
+	invalids := map[string]string{
+		"理由がない":   `[{"rule": "hidden-column"}]`,
+		"ルールがない":  `[{"reason": "r"}]`,
+		"期限の書式":   `[{"rule": "hidden-column", "reason": "r", "expires": "2026-12-31"}]`,
+		"JSONでない": `rule=hidden-column`,
+	}
+	for name, content := range invalids {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadIgnores(write(t, content)); err == nil {
+				t.Error("不正な抑制設定でエラーが返されませんでした")
+			}
+		})
+	}
+}
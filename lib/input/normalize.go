@@ -0,0 +1,107 @@
+package input
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"pncheck/lib/config"
+)
+
+// Change : 正規化などでpncheckが書き換えたセルの値
+type Change struct {
+	Sheet  string // シート名
+	Cell   string // セル番地 (例: "E2")
+	Field  string // 項目名 (例: "品番")
+	Before string // 書き換え前の値
+	After  string // 書き換え後の値
+}
+
+// String : レポート表示用の文字列
+// 不可視文字が見えるように書き換え前後の値はクォートして表示する
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s %s: %s → %s",
+		c.Sheet, c.Cell, c.Field, strconv.Quote(c.Before), strconv.Quote(c.After))
+}
+
+// normalizeTarget : 正規化の対象となる明細の項目
+type normalizeTarget struct {
+	field string               // 項目名
+	col   string               // 入力Ⅰの列
+	value func(*Order) *string // Orderの該当フィールド
+}
+
+// normalizeTargets : 正規化できる明細の項目
+var normalizeTargets = []normalizeTarget{
+	{"品番", colPid, func(o *Order) *string { return &o.Pid }},
+	{"品名", colName, func(o *Order) *string { return &o.Name }},
+	{"型式", colType, func(o *Order) *string { return &o.Type }},
+	{"メーカ", colMaker, func(o *Order) *string { return &o.Maker }},
+	{"要望先", colVendor, func(o *Order) *string { return &o.Vendor }},
+	{"単位", colUnit, func(o *Order) *string { return &o.Unit }},
+}
+
+// Normalize はPNSearchへ送る前に明細の文字列を正規化し、
+// 書き換えたすべての値をChangeとして返します。
+//
+// ユーザーがExcelの元データを修正できるよう、
+// 値が変わらなかった項目はChangeに含めません。
+func Normalize(sheet *Sheet, cfg config.Normalize) (changes []Change) {
+	for i := range sheet.Orders {
+		o := &sheet.Orders[i]
+		for _, t := range normalizeTargets {
+			steps := cfg.Fields[t.field]
+			if len(steps) == 0 {
+				continue
+			}
+			v := t.value(o)
+			after := normalizeText(*v, steps)
+			if after == *v {
+				continue
+			}
+			changes = append(changes, Change{
+				Sheet:  orderSheetName,
+				Cell:   t.col + strconv.Itoa(o.Row),
+				Field:  t.field,
+				Before: *v,
+				After:  after,
+			})
+			*v = after
+		}
+	}
+	return
+}
+
+// normalizeText : stepsに指定された正規化処理を順に適用する
+func normalizeText(s string, steps []string) string {
+	if slices.Contains(steps, config.StepInvisible) {
+		s = removeInvisible(s)
+	}
+	if slices.Contains(steps, config.StepNFKC) {
+		s = norm.NFKC.String(s)
+	}
+	if slices.Contains(steps, config.StepSpace) {
+		s = collapseSpace(s)
+	}
+	return s
+}
+
+// removeInvisible : ゼロ幅スペースやBOMなどの書式文字と制御文字を取り除く
+func removeInvisible(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Cf, r) || (unicode.IsControl(r) && !unicode.IsSpace(r)) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// collapseSpace : NBSPや全角スペースを含む空白の連続を半角スペース1つにまとめ、
+// 前後の空白を取り除く
+func collapseSpace(s string) string {
+	return strings.Join(strings.FieldsFunc(s, unicode.IsSpace), " ")
+}
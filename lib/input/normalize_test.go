@@ -0,0 +1,54 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+
+	"pncheck/lib/config"
+)
+
+func TestNormalizeText(t *testing.T) {
+	all := []string{config.StepNFKC, config.StepSpace, config.StepInvisible}
+	tests := []struct {
+		name  string
+		input string
+		steps []string
+		want  string
+	}{
+		{"全角英数字", "ＡＢＣ－１２３", all, "ABC-123"},
+		{"半角カナ", "ﾎﾞﾙﾄ", all, "ボルト"},
+		{"NBSPと全角スペース", "六角\u00a0ボルト\u3000M6", all, "六角 ボルト M6"},
+		{"連続する空白と前後の空白", "  部品   A  ", all, "部品 A"},
+		{"ゼロ幅スペースとBOM", "\ufeffPN\u200b-001", all, "PN-001"},
+		{"正規化なし", "ＡＢＣ", nil, "ＡＢＣ"},
+		{"不可視文字のみ", "Ａ\u200bＢ", []string{config.StepInvisible}, "ＡＢ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeText(tt.input, tt.steps); got != tt.want {
+				t.Errorf("normalizeText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	sheet := Sheet{Orders: Orders{
+		{Row: 2, Pid: "ＰＮ－００１", Name: "部品A", Unit: "個"},
+		{Row: 5, Pid: "PN-002", Name: "ﾎﾞﾙﾄ", Maker: "Maker X"},
+	}}
+	cfg := config.Default().Normalize
+	cfg.Fields["メーカ"] = nil // メーカは正規化しない
+
+	got := Normalize(&sheet, cfg)
+	want := []Change{
+		{Sheet: orderSheetName, Cell: "E2", Field: "品番", Before: "ＰＮ－００１", After: "PN-001"},
+		{Sheet: orderSheetName, Cell: "F5", Field: "品名", Before: "ﾎﾞﾙﾄ", After: "ボルト"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize() = %+v, want %+v", got, want)
+	}
+	if sheet.Orders[0].Pid != "PN-001" || sheet.Orders[1].Maker != "Maker X" {
+		t.Errorf("Sheetが正規化されていません: %+v", sheet.Orders)
+	}
+}
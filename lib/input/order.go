@@ -1,9 +1,9 @@
 package input
 
 import (
-	"path/filepath"
-	"strings"
-	"time"
+	"strconv"
+
+	"pncheck/lib/i18n"
 )
 
 type OrderType string
@@ -15,36 +15,164 @@ const (
 	組部品 OrderType = "組部品" // 不正な区分の場合
 )
 
-// parseOrderType ファイル名を引数に、"-"で区切った最後のブロックの値で発注区分を返す
-// S: 出庫 OrderType = "出庫"
-// K: 購入 OrderType = "購入"
-// G: 外注 OrderType = "外注"
-// それ以外: 組部品 OrderType = "組部品" // 不正な区分の場合
-func parseOrderType(filePath string) OrderType {
-	base := filepath.Base(filePath)
-	// "-"で区切って4ブロック目の最初の文字
-	blocks := strings.Split(base, "-")
-	// fmt.Println("[DEBUG] parseOrderType() split filename: ", blocks)
-	if len(blocks) < 4 {
-		return "組部品"
-	}
-	lastBlock := blocks[3]
-	// OrderTypeを決定
+// inferOrderType はシートの内容から発注区分を推定し、推定の根拠とともに返します。
+// 推定できない場合は空文字を返します。
+//
+//	組部品: 出庫指示番号(AJ3)がある、または製番の10桁目が6
+//	出庫: すべての行に予定単価も要望先もない
+//	購入: 過半数の行にメーカがある
+//	外注: 要望先がある
+func inferOrderType(sheet *Sheet) (OrderType, string) {
+	if sheet.Remark != "" {
+		return 組部品, i18n.T("出庫指示番号(%s)が %s に入力されています", sheet.Remark, remarkCell)
+	}
+	if isAssyProject(sheet.ProjectID) {
+		return 組部品, i18n.T("製番 %s の%d桁目が%dです", sheet.ProjectID, projectAssyDigit+1, projectAssyValue)
+	}
+	if len(sheet.Orders) == 0 {
+		return "", ""
+	}
+
+	var priced, vendors, makers int
+	for _, o := range sheet.Orders {
+		if o.UnitPrice != 0 {
+			priced++
+		}
+		if o.Vendor != "" {
+			vendors++
+		}
+		if o.Maker != "" {
+			makers++
+		}
+	}
 	switch {
-	case strings.HasPrefix(lastBlock, "S"):
-		return 出庫
-	case strings.HasPrefix(lastBlock, "K"):
-		return 購入
-	case strings.HasPrefix(lastBlock, "G"):
-		return 外注
+	case priced == 0 && vendors == 0:
+		return 出庫, i18n.T("すべての行に予定単価と要望先がありません")
+	case makers*2 > len(sheet.Orders):
+		return 購入, i18n.T("%d行中%d行にメーカが入力されています", len(sheet.Orders), makers)
+	case vendors > 0:
+		return 外注, i18n.T("%d行中%d行に要望先が入力され、メーカが少ないです", len(sheet.Orders), vendors)
 	default:
-		return 組部品
+		return "", ""
+	}
+}
+
+// isAssyProject : 製番の10桁目が6なら組部品の製番
+func isAssyProject(prjID string) bool {
+	if len(prjID) < projectIDLength {
+		return false
+	}
+	class, err := strconv.Atoi(prjID[projectAssyDigit : projectAssyDigit+1])
+	return err == nil && class == projectAssyValue
+}
+
+// resolveOrderType : ファイル名から発注区分が決まらなければ
+// シートの内容から推定した発注区分を使う
+func resolveOrderType(fn Filename, sheet *Sheet) OrderType {
+	if t := fn.orderType(); t != "" {
+		return t
+	}
+	if t, _ := inferOrderType(sheet); t != "" {
+		return t
 	}
+	return 組部品
 }
 
-func parseFilenameDate(filePath string) (time.Time, error) {
-	const layout = "20060102"
-	b := filepath.Base(filePath)
-	ss := strings.Split(b, "-")
-	return time.Parse(layout, ss[0])
+// validateOrderType はファイル名の発注区分とシートの内容から推定した発注区分を比較します。
+// ファイル名から発注区分が決まらず内容から推定した場合も、その旨を警告します。
+func validateOrderType(fn Filename, sheet *Sheet) (warns []string) {
+	inferred, reason := inferOrderType(sheet)
+	if inferred == "" {
+		return nil
+	}
+	named := fn.orderType()
+	switch {
+	case named == "":
+		warns = append(warns, i18n.T(
+			"ファイル名から発注区分が決まらないため、シートの内容から %s と推定しました: %s",
+			inferred, reason))
+	case named != inferred:
+		warns = append(warns, i18n.T(
+			"ファイル名の発注区分 %s(%s) とシートの内容から推定した発注区分 %s が一致しません: %s",
+			named, fn.OrderCode, inferred, reason))
+	}
+	return
+}
+
+// requiredField : 発注区分ごとに明細に必要な項目
+type requiredField struct {
+	field string
+	col   string
+	ok    func(o *Order) bool
+}
+
+// orderTypeRequiredFields : 発注区分ごとに全明細で満たすべき項目
+var orderTypeRequiredFields = map[OrderType][]requiredField{
+	外注: {
+		{"要望先", colVendor, func(o *Order) bool { return o.Vendor != "" }},
+		{"予定単価", colUnitPrice, func(o *Order) bool { return o.UnitPrice > 0 }},
+	},
+	購入: {
+		{"メーカ", colMaker, func(o *Order) bool { return o.Maker != "" }},
+		{"型式", colType, func(o *Order) bool { return o.Type != "" }},
+	},
+}
+
+// validateOrderTypeFields は発注区分ごとに必要な項目が入力されているか検証し、
+// 問題のある行ごとにFindingを返します。
+//
+//	外注: 要望先と予定単価が必要
+//	購入: メーカと型式が必要
+//	出庫: 出庫指示番号がなく、予定単価が0か空欄
+//	組部品: 出庫指示番号(数字)が必要
+func validateOrderTypeFields(sheet *Sheet) (findings []Finding) {
+	t := sheet.OrderType
+	for _, rf := range orderTypeRequiredFields[t] {
+		for _, o := range sheet.Orders {
+			if rf.ok(&o) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Sheet: orderSheetName,
+				Row:   o.Row,
+				Cell:  rf.col + strconv.Itoa(o.Row),
+				Message: i18n.T("明細(%s) %d行目: %sの明細には%s(%s列)が必要です",
+					orderSheetName, o.Row, t, rf.field, rf.col),
+			})
+		}
+	}
+
+	switch t {
+	case 出庫:
+		if sheet.Remark != "" {
+			findings = append(findings, Finding{
+				Sheet: orderSheetName,
+				Cell:  remarkCell,
+				Message: i18n.T("%s %s: %sに出庫指示番号(%s)は不要です。組部品ではありませんか",
+					orderSheetName, remarkCell, t, sheet.Remark),
+			})
+		}
+		for _, o := range sheet.Orders {
+			if o.UnitPrice == 0 {
+				continue
+			}
+			findings = append(findings, Finding{
+				Sheet: orderSheetName,
+				Row:   o.Row,
+				Cell:  colUnitPrice + strconv.Itoa(o.Row),
+				Message: i18n.T("明細(%s) %d行目: %sの明細の予定単価(%s列)は0か空欄にしてください: %g",
+					orderSheetName, o.Row, t, colUnitPrice, o.UnitPrice),
+			})
+		}
+	case 組部品:
+		if sheet.Remark == "" {
+			findings = append(findings, Finding{
+				Sheet: orderSheetName,
+				Cell:  remarkCell,
+				Message: i18n.T("%s %s: %sには「出庫指示番号33690による」のように数字の出庫指示番号が必要です",
+					orderSheetName, remarkCell, t),
+			})
+		}
+	}
+	return
 }
@@ -1,61 +1,133 @@
 package input
 
 import (
+	"reflect"
 	"testing"
 )
 
-func TestParseOrderType(t *testing.T) {
-
+func TestInferOrderType(t *testing.T) {
 	tests := []struct {
-		name     string
-		filePath string
-		expected OrderType
+		name  string
+		sheet Sheet
+		want  OrderType
 	}{
-		// --- Happy Path Cases (Valid Endings) ---
-		{"Valid S Ending", "path/to/222-some-file-S", 出庫},
-		{"Valid K Ending", "another/dir/222-222-data-K", 購入},
-		{"Valid G Ending", "just-a-name-G", 外注},
-		{"Valid S Ending - No Path", "file-tbd-20-S-2", 出庫},
-		{"Valid K Ending - No Path", "doc-123--K-1", 購入},
-		{"Valid G Ending - No Path", "2002-1234-tbd-G-その3", 外注},
-		{"Valid S Ending - Multiple Hyphens", "prefix-middle-suffix-S", 出庫},
-		{"Valid K Ending - Multiple Hyphens", "a-b-c-K", 購入},
-		{"Valid G Ending - Multiple Hyphens", "x-y-z-G", 外注},
-		{"Valid S Ending - Hyphen at Start", "202-231-tbd-S", 出庫}, // filepath.Base is "-file-S", split is ["", "file", "S"]
-
-		// --- Unhappy Path Cases (Invalid Endings / Default) ---
-		{"Invalid Ending - X", "file-X", 組部品},
-		{"Invalid Ending - ABC", "data-abc", 組部品},
-		{"Invalid Ending - Number", "report-123", 組部品},
-		{"Invalid Ending - Empty String after Hyphen", "file-", 組部品}, // Split results in ["file", ""], last is ""
-		{"Invalid Ending - Lowercase s", "file--s", 組部品},             // Case sensitive
-		{"Invalid Ending - Lowercase k", "file---k", 組部品},
-		{"Invalid Ending - Lowercase g", "file--g", 組部品},
-		{"Invalid Ending - Mixed Case", "file--S ", 組部品}, // Trailing space
+		{
+			name:  "出庫指示番号があれば組部品",
+			sheet: Sheet{Header: Header{Remark: "33690"}, Orders: Orders{{Vendor: "VendorY", UnitPrice: 100}}},
+			want:  組部品,
+		},
+		{
+			name:  "製番の10桁目が6なら組部品",
+			sheet: Sheet{Header: Header{ProjectID: "123456789600"}, Orders: Orders{{}}},
+			want:  組部品,
+		},
+		{
+			name:  "予定単価も要望先もなければ出庫",
+			sheet: Sheet{Orders: Orders{{Pid: "A"}, {Pid: "B", Maker: "MakerX"}}},
+			want:  出庫,
+		},
+		{
+			name:  "メーカが多ければ購入",
+			sheet: Sheet{Orders: Orders{{Maker: "MakerX", UnitPrice: 100}, {Maker: "MakerY", Vendor: "VendorY"}}},
+			want:  購入,
+		},
+		{
+			name:  "要望先がありメーカが少なければ外注",
+			sheet: Sheet{Orders: Orders{{Vendor: "VendorY", UnitPrice: 100}, {Vendor: "VendorY"}}},
+			want:  外注,
+		},
+		{
+			name:  "明細がなければ推定できない",
+			sheet: Sheet{},
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := inferOrderType(&tt.sheet); got != tt.want {
+				t.Errorf("inferOrderType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
 
-		// --- Edge Cases (No Hyphens, Empty, etc.) ---
-		{"No Hyphens - Just S", "S", 組部品}, // Last block is "S", but not after a hyphen
-		{"No Hyphens - Just K", "K", 組部品},
-		{"No Hyphens - Just G", "G", 組部品},
-		{"No Hyphens - Regular Filename", "myfile.txt", 組部品},       // Last block is "myfile.txt"
-		{"No Hyphens - Filename with Dots", "archive.tar.gz", 組部品}, // Last block is "archive.tar.gz"
-		{"Empty String Input", "", 組部品},                            // filepath.Base("") is ".", Split(".") is [".", ""], last is "" -> "組部品" (behavior might vary slightly by OS, but "." or "" are common)
-		{"Just a Hyphen", "-", 組部品},                                // filepath.Base("-") is "-", Split("-") is ["", ""], last is "" -> "組部品"
-		{"Hyphen at End", "file-S-", 組部品},                          // filepath.Base("file-S-") is "file-S-", Split is ["file", "S", ""], last is "" -> "組部品"
-		{"Hyphen at Start and End", "-file-S-", 組部品},               // filepath.Base is "-file-S-", Split is ["", "file", "S", ""], last is "" -> "組部品"
+func TestValidateOrderType(t *testing.T) {
+	outsourced := &Sheet{Orders: Orders{{Vendor: "VendorY", UnitPrice: 100}}}
 
-		// --- Directory Path Cases ---
-		{"Directory Path - No File", "/path/to/dir/", 組部品}, // filepath.Base is "dir"
-		{"Root Directory Path", "/", 組部品},                  // filepath.Base is "/"
-		{"Current Directory", ".", 組部品},                    // filepath.Base is "."
-		{"Parent Directory", "..", 組部品},                    // filepath.Base is ".."
+	tests := []struct {
+		name      string
+		filePath  string
+		sheet     *Sheet
+		wantWarns int
+		wantType  OrderType
+	}{
+		{"ファイル名と内容が一致", "20231027-123456789000-S001-G.xlsx", outsourced, 0, 外注},
+		{"ファイル名と内容が不一致", "20231027-123456789000-S001-K.xlsx", outsourced, 1, 購入},
+		{"ファイル名から決まらないので内容から推定", "20231027-123456789000-S001.xlsx", outsourced, 1, 外注},
+		{"ファイル名からも内容からも決まらない", "20231027-123456789000-S001.xlsx", &Sheet{}, 0, 組部品},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := ParseFilename(tt.filePath)
+			if warns := validateOrderType(fn, tt.sheet); len(warns) != tt.wantWarns {
+				t.Errorf("validateOrderType() = %v, want %d warnings", warns, tt.wantWarns)
+			}
+			if got := resolveOrderType(fn, tt.sheet); got != tt.wantType {
+				t.Errorf("resolveOrderType() = %q, want %q", got, tt.wantType)
+			}
+		})
+	}
+}
 
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			actual := parseOrderType(tc.filePath)
-			if actual != tc.expected {
-				t.Errorf("parseOrderType(%q): Expected %q, Got %q", tc.filePath, tc.expected, actual)
+func TestValidateOrderTypeFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		sheet     Sheet
+		wantCells []string
+	}{
+		{
+			name: "外注は要望先と予定単価が必要",
+			sheet: Sheet{Header: Header{OrderType: 外注}, Orders: Orders{
+				{Vendor: "VendorY", UnitPrice: 100, Row: 2},
+				{Vendor: "", UnitPrice: 0, Row: 3},
+			}},
+			wantCells: []string{"BF3", "BG3"},
+		},
+		{
+			name: "購入はメーカと型式が必要",
+			sheet: Sheet{Header: Header{OrderType: 購入}, Orders: Orders{
+				{Maker: "MakerX", Type: "", Row: 2},
+				{Maker: "MakerX", Type: "T-1", Row: 5},
+			}},
+			wantCells: []string{"G2"},
+		},
+		{
+			name: "出庫は出庫指示番号がなく予定単価が0",
+			sheet: Sheet{Header: Header{OrderType: 出庫, Remark: "33690"}, Orders: Orders{
+				{UnitPrice: 0, Row: 2},
+				{UnitPrice: 500, Row: 3},
+			}},
+			wantCells: []string{remarkCell, "BG3"},
+		},
+		{
+			name:      "組部品は出庫指示番号が必要",
+			sheet:     Sheet{Header: Header{OrderType: 組部品}, Orders: Orders{{Row: 2}}},
+			wantCells: []string{remarkCell},
+		},
+		{
+			name:      "組部品で出庫指示番号あり",
+			sheet:     Sheet{Header: Header{OrderType: 組部品, Remark: "33690"}, Orders: Orders{{Row: 2}}},
+			wantCells: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range validateOrderTypeFields(&tt.sheet) {
+				got = append(got, f.Cell)
+			}
+			if !reflect.DeepEqual(got, tt.wantCells) {
+				t.Errorf("validateOrderTypeFields() cells = %v, want %v", got, tt.wantCells)
 			}
 		})
 	}
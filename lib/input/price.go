@@ -0,0 +1,148 @@
+package input
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/config"
+	"pncheck/lib/i18n"
+)
+
+const (
+	printOrdersStartRow = 13   // 印刷シートの明細が始まる行
+	colPrintAmount      = "AY" // 印刷シートの金額列
+)
+
+// orderRefPattern : 数式の中の入力Ⅰのセル参照 (例: 入力Ⅰ!$X$2, '入力Ⅰ'!BG2)
+var orderRefPattern = regexp.MustCompile(`'?` + orderSheetName + `'?!\$?[A-Z]{1,3}\$?(\d+)`)
+
+func init() {
+	Register(priceRules)
+}
+
+// priceRules : 設定の端数処理を反映した金額のルールを作る
+func priceRules(cfg *config.Config) ([]Rule, error) {
+	price := cfg.Price
+	return []Rule{
+		ruleFunc{
+			id:          "row-price",
+			description: "印刷シートの明細ごとの金額が数量×予定単価と一致すること",
+			severity:    SeverityWarning, // 印刷シートの行を入力Ⅰの行から推定するため
+			check: func(_ *Workbook, sheet *Sheet) []Finding {
+				return validatePrices(sheet, price)
+			},
+		},
+	}, nil
+}
+
+// printSheet : 明細の金額を読み込む印刷シート
+type printSheet struct {
+	name    string
+	lastRow int // 合計行の1つ上の行
+}
+
+// printSheets : 存在して金額列の範囲がわかる印刷シートを返す
+func printSheets(f *excelize.File) (sheets []printSheet) {
+	for _, name := range sheetsToValidate {
+		if name == headerSheetName {
+			continue
+		}
+		if i, err := f.GetSheetIndex(name); err != nil || i < 0 {
+			continue
+		}
+		config, err := getSheetValidationConfig(f, name)
+		if err != nil {
+			continue // 合計計算設定のエラーは validateExcelSums が報告する
+		}
+		_, lastRow, err := excelize.SplitCellName(config.cellSum)
+		if err != nil {
+			continue
+		}
+		sheets = append(sheets, printSheet{name, lastRow - 1})
+	}
+	return
+}
+
+// readPrices は入力Ⅰの各明細に対応する印刷シートの金額をPriceに読み込みます。
+//
+// 印刷シートの明細は入力Ⅰの行を上から順に13行目以降へ並べたものなので、
+// 入力Ⅰの行番号から印刷シートの行番号を求める。
+// 品目数によって使われる印刷シートが異なるため、行が収まる最初の印刷シートから読み込む。
+// 金額の数式が入力Ⅰの別の行を参照していれば、対応を推定できないので読み込まない。
+func (orders Orders) readPrices(f *excelize.File) {
+	sheets := printSheets(f)
+	for i := range orders {
+		o := &orders[i]
+		row := printOrdersStartRow + o.Row - ordersStartRow
+		for _, s := range sheets {
+			if row > s.lastRow {
+				continue
+			}
+			cell := colPrintAmount + strconv.Itoa(row)
+			if !refersToRow(f, s.name, cell, o.Row) {
+				break
+			}
+			o.Price = getFloatCellValue(f, s.name, cell)
+			o.priceSheet, o.priceCell = s.name, cell
+			break
+		}
+	}
+}
+
+// refersToRow : セルの数式が入力Ⅰを参照していれば、すべての参照がrow行目のときにtrueを返す。
+// 入力Ⅰを参照しない数式や値だけのセルは確かめられないのでtrueを返す
+func refersToRow(f *excelize.File, sheetName, cell string, row int) bool {
+	formula, err := f.GetCellFormula(sheetName, cell)
+	if err != nil {
+		return true
+	}
+	for _, m := range orderRefPattern.FindAllStringSubmatch(formula, -1) {
+		if m[1] != strconv.Itoa(row) {
+			return false
+		}
+	}
+	return true
+}
+
+// roundPrice : 設定の端数処理を適用する
+func roundPrice(v float64, cfg config.Price) float64 {
+	p := math.Pow10(cfg.Digits)
+	// 0.1×3 のような浮動小数点の誤差で端数処理の結果が変わらないよう、先に細かい桁で丸める
+	v = math.Round(v*p*1e6) / 1e6
+	switch cfg.Rounding {
+	case config.RoundingFloor:
+		return math.Floor(v) / p
+	case config.RoundingCeil:
+		return math.Ceil(v) / p
+	case config.RoundingNone:
+		return v / p
+	default:
+		return math.Round(v) / p
+	}
+}
+
+// validatePrices は印刷シートの金額が数量×予定単価に端数処理を適用した値と一致するか検証し、
+// 一致しない行ごとにFindingを返します。印刷シートから読み込めなかった行は確認しません。
+func validatePrices(sheet *Sheet, cfg config.Price) (findings []Finding) {
+	for _, o := range sheet.Orders {
+		if o.priceSheet == "" {
+			continue
+		}
+		want := roundPrice(o.Quantity*o.UnitPrice, cfg)
+		if math.Abs(o.Price-want) < formulaCacheTolerance {
+			continue
+		}
+		_, row, _ := excelize.SplitCellName(o.priceCell)
+		findings = append(findings, Finding{
+			Sheet: o.priceSheet,
+			Row:   row,
+			Cell:  o.priceCell,
+			Message: i18n.T("明細(%s) %d行目: %s の金額 %g が 数量 %g × 予定単価 %g = %g と一致しません",
+				orderSheetName, o.Row, o.priceSheet+"!"+o.priceCell, o.Price, o.Quantity, o.UnitPrice, want),
+		})
+	}
+	return
+}
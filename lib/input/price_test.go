@@ -0,0 +1,89 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/config"
+)
+
+func TestValidatePrices(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+	_, _ = f.NewSheet(printSheetNameDefault)
+	f.SetCellValue(printSheetNameDefault, "AY13", 1055)  // 入力Ⅰ 2行目: 10.5 × 100.5 = 1055.25
+	f.SetCellValue(printSheetNameDefault, "AY14", 12000) // 入力Ⅰ 3行目: 5 × 2500 = 12500 (誤り)
+	f.SetCellValue(printSheetNameDefault, "AY15", 999)   // 入力Ⅰ 4行目: 数式が別の行を参照
+	f.SetCellFormula(printSheetNameDefault, "AY15", "入力Ⅰ!$X$9*'入力Ⅰ'!BG9")
+	f.SetCellValue(printSheetNameDefault, "AY16", 300) // 入力Ⅰ 5行目: 3 × 100 = 300
+	f.SetCellFormula(printSheetNameDefault, "AY16", "入力Ⅰ!$X$5*入力Ⅰ!BG5")
+	f.SetCellValue(printSheetNameDefault, "AU17", "合計") // 17行目が合計なので入力Ⅰ 6行目以降は対応しない
+
+	orders := Orders{
+		{Quantity: 10.5, UnitPrice: 100.5, Row: 2},
+		{Quantity: 5, UnitPrice: 2500, Row: 3},
+		{Quantity: 1, UnitPrice: 100, Row: 4},
+		{Quantity: 3, UnitPrice: 100, Row: 5},
+		{Quantity: 1, UnitPrice: 100, Row: 6},
+	}
+	orders.readPrices(f)
+	if o := orders[1]; o.Price != 12000 || o.priceSheet != printSheetNameDefault || o.priceCell != "AY14" {
+		t.Errorf("readPrices() 3行目 = %g (%s!%s), want 12000 (10品目用!AY14)", o.Price, o.priceSheet, o.priceCell)
+	}
+	if o := orders[2]; o.priceSheet != "" {
+		t.Errorf("readPrices() 4行目 = %s!%s, want 別の行を参照するので読み込まない", o.priceSheet, o.priceCell)
+	}
+	if o := orders[3]; o.Price != 300 || o.priceCell != "AY16" {
+		t.Errorf("readPrices() 5行目 = %g (%s), want 300 (AY16)", o.Price, o.priceCell)
+	}
+	if o := orders[4]; o.priceSheet != "" {
+		t.Errorf("readPrices() 6行目 = %s!%s, want 読み込まない", o.priceSheet, o.priceCell)
+	}
+
+	tests := []struct {
+		name      string
+		price     config.Price
+		wantCells []string
+	}{
+		{"四捨五入", config.Price{Rounding: config.RoundingRound}, []string{"10品目用!AY14"}},
+		{"切り上げ", config.Price{Rounding: config.RoundingCeil}, []string{"10品目用!AY13", "10品目用!AY14"}},
+		{"端数処理なし", config.Price{Rounding: config.RoundingNone, Digits: 2}, []string{"10品目用!AY13", "10品目用!AY14"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range validatePrices(&Sheet{Orders: orders}, tt.price) {
+				got = append(got, f.Sheet+"!"+f.Cell)
+			}
+			if len(got) != len(tt.wantCells) {
+				t.Fatalf("validatePrices() cells = %v, want %v", got, tt.wantCells)
+			}
+			for i := range got {
+				if got[i] != tt.wantCells[i] {
+					t.Errorf("validatePrices() cells = %v, want %v", got, tt.wantCells)
+				}
+			}
+		})
+	}
+}
+
+func TestRoundPrice(t *testing.T) {
+	tests := []struct {
+		v     float64
+		price config.Price
+		want  float64
+	}{
+		{1055.25, config.Price{Rounding: config.RoundingRound}, 1055},
+		{1055.5, config.Price{Rounding: config.RoundingRound}, 1056},
+		{1055.25, config.Price{Rounding: config.RoundingFloor}, 1055},
+		{1055.25, config.Price{Rounding: config.RoundingCeil}, 1056},
+		{1055.255, config.Price{Rounding: config.RoundingRound, Digits: 2}, 1055.26},
+		{0.1 * 3, config.Price{Rounding: config.RoundingCeil, Digits: 1}, 0.3},
+	}
+	for _, tt := range tests {
+		if got := roundPrice(tt.v, tt.price); got != tt.want {
+			t.Errorf("roundPrice(%v, %+v) = %v, want %v", tt.v, tt.price, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package input
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"pncheck/lib/config"
+	"pncheck/lib/i18n"
+)
+
+func init() {
+	Register(quantityRules)
+}
+
+// quantityRules : 設定の単位の辞書を反映した数量と単位のルールを作る
+func quantityRules(cfg *config.Config) ([]Rule, error) {
+	units := cfg.Units
+	return []Rule{
+		ruleFunc{
+			id:          "quantity",
+			description: "数量が0以上で、個や本などの単位では整数であること (購入と外注は0も不可)",
+			severity:    SeverityError,
+			check: func(_ *Workbook, sheet *Sheet) []Finding {
+				return validateQuantities(sheet, units.Countable)
+			},
+		},
+		ruleFunc{
+			id:          "unit",
+			description: "単位が設定ファイルの units.allowed に含まれること",
+			severity:    SeverityWarning,
+			check: func(_ *Workbook, sheet *Sheet) []Finding {
+				return validateUnits(sheet, units.Allowed)
+			},
+		},
+	}, nil
+}
+
+// NormalizeUnits は単位の表記ゆれ(ヶ, コ など)を設定の対応表に従って書き換え、
+// 書き換えたすべての値をChangeとして返します。
+func NormalizeUnits(sheet *Sheet, units config.Units) (changes []Change) {
+	for i := range sheet.Orders {
+		o := &sheet.Orders[i]
+		to, ok := units.Synonyms[o.Unit]
+		if !ok || to == o.Unit {
+			continue
+		}
+		changes = append(changes, Change{
+			Sheet:  orderSheetName,
+			Cell:   colUnit + strconv.Itoa(o.Row),
+			Field:  "単位",
+			Before: o.Unit,
+			After:  to,
+		})
+		o.Unit = to
+	}
+	return
+}
+
+// validateQuantities は明細の数量について、
+// 負でないこと、購入と外注では0でないこと、countableの単位では整数であることを検証し、
+// 問題のある行ごとにFindingを返します。
+func validateQuantities(sheet *Sheet, countable []string) (findings []Finding) {
+	mustOrder := sheet.OrderType == 購入 || sheet.OrderType == 外注
+	for _, o := range sheet.Orders {
+		var reason string
+		switch {
+		case o.Quantity < 0:
+			reason = i18n.T("負の数です")
+		case o.Quantity == 0 && mustOrder:
+			reason = i18n.T("0です。%sの明細には数量が必要です", sheet.OrderType)
+		case slices.Contains(countable, o.Unit) && o.Quantity != math.Trunc(o.Quantity):
+			reason = i18n.T("単位が %s なのに整数ではありません", o.Unit)
+		default:
+			continue
+		}
+		findings = append(findings, Finding{
+			Sheet: orderSheetName,
+			Row:   o.Row,
+			Cell:  colQuantity + strconv.Itoa(o.Row),
+			Message: i18n.T("明細(%s) %d行目: 数量(%s列) %g が%s",
+				orderSheetName, o.Row, colQuantity, o.Quantity, reason),
+		})
+	}
+	return
+}
+
+// validateUnits は明細の単位が辞書にあるか検証し、問題のある行ごとにFindingを返します。
+// 辞書が空なら確認しません。
+func validateUnits(sheet *Sheet, allowed []string) (findings []Finding) {
+	if len(allowed) == 0 {
+		return
+	}
+	for _, o := range sheet.Orders {
+		if slices.Contains(allowed, o.Unit) {
+			continue
+		}
+		reason := i18n.T("'%s' は使える単位ではありません", o.Unit)
+		if o.Unit == "" {
+			reason = i18n.T("空欄です")
+		}
+		findings = append(findings, Finding{
+			Sheet: orderSheetName,
+			Row:   o.Row,
+			Cell:  colUnit + strconv.Itoa(o.Row),
+			Message: i18n.T("明細(%s) %d行目: 単位(%s列)が%s。使える単位: %s",
+				orderSheetName, o.Row, colUnit, reason, strings.Join(allowed, ", ")),
+		})
+	}
+	return
+}
@@ -0,0 +1,77 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+
+	"pncheck/lib/config"
+)
+
+func TestValidateQuantities(t *testing.T) {
+	countable := config.Default().Units.Countable
+	orders := Orders{
+		{Quantity: 2, Unit: "個", Row: 2},
+		{Quantity: -1, Unit: "個", Row: 3},  // 負の数
+		{Quantity: 0, Unit: "個", Row: 4},   // 購入と外注では不可
+		{Quantity: 1.5, Unit: "本", Row: 5}, // 数える単位で小数
+		{Quantity: 1.5, Unit: "m", Row: 6},
+	}
+	tests := []struct {
+		orderType OrderType
+		want      []string
+	}{
+		{購入, []string{"I3", "I4", "I5"}},
+		{出庫, []string{"I3", "I5"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.orderType), func(t *testing.T) {
+			sheet := &Sheet{Header: Header{OrderType: tt.orderType}, Orders: orders}
+			var got []string
+			for _, f := range validateQuantities(sheet, countable) {
+				got = append(got, f.Cell)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateQuantities() cells = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateUnits(t *testing.T) {
+	sheet := &Sheet{Orders: Orders{
+		{Unit: "個", Row: 2},
+		{Unit: "ダース", Row: 3},
+		{Unit: "", Row: 4},
+	}}
+	var got []string
+	for _, f := range validateUnits(sheet, []string{"個", "式"}) {
+		got = append(got, f.Cell)
+	}
+	if want := []string{"BE3", "BE4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("validateUnits() cells = %v, want %v", got, want)
+	}
+	if f := validateUnits(sheet, nil); f != nil {
+		t.Errorf("validateUnits() with empty dictionary = %v, want nil", f)
+	}
+}
+
+func TestNormalizeUnits(t *testing.T) {
+	sheet := &Sheet{Orders: Orders{
+		{Unit: "ヶ", Row: 2},
+		{Unit: "個", Row: 3},
+		{Unit: "コ", Row: 4},
+	}}
+	changes := NormalizeUnits(sheet, config.Default().Units)
+	want := []Change{
+		{Sheet: orderSheetName, Cell: "BE2", Field: "単位", Before: "ヶ", After: "個"},
+		{Sheet: orderSheetName, Cell: "BE4", Field: "単位", Before: "コ", After: "個"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("NormalizeUnits() = %v, want %v", changes, want)
+	}
+	for _, o := range sheet.Orders {
+		if o.Unit != "個" {
+			t.Errorf("%d行目の単位 = %q, want 個", o.Row, o.Unit)
+		}
+	}
+}
@@ -6,15 +6,20 @@ inputパッケージでは エクセルファイルの読込みを主に担当
 - order.go : 発注区分の決定をサポートします。
 
 - sheet.go : ExcelのデータをPNSearch APIへ送るのに適したJSON型 Sheet構造体へ変換します。
+
+- date.go : 和暦や全角数字を含む日付文字列をPNSearchが求める日付型へ変換します。
 */
 package input
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/i18n"
 )
 
 type sheetValidationConfig struct {
@@ -23,42 +28,85 @@ type sheetValidationConfig struct {
 	upperSumCell string // AX7 for print sheets, O7 for InputII
 }
 
-// ReadExcelToSheet は指定されたExcelファイルを読み込み、Sheet構造体に変換します。
-// Excelのレイアウトは提供された書き込みコードに基づいて定数で定義されたものを仮定しています。
-func ReadExcelToSheet(filePath string) (sheet Sheet, err error) {
-	if err = validateFile(filePath); err != nil {
-		return
+// Workbook : 1回だけ開いたExcelファイル
+// 読み込みとすべてのローカル検証で共有し、ネットワーク共有上のファイルでもI/Oを1回にする
+type Workbook struct {
+	*excelize.File
+	Path string // 開いたファイルのパス
+}
+
+// OpenWorkbook はファイルタイプを検証してExcelファイルを開きます。
+// 使い終わったら Close を呼ぶ必要があります。
+func OpenWorkbook(filePath string) (*Workbook, error) {
+	if err := validateFile(filePath); err != nil {
+		return nil, err
 	}
 
 	opts := excelize.Options{RawCellValue: true}
 	f, err := excelize.OpenFile(filePath, opts)
 	if err != nil {
-		return sheet, fmt.Errorf("ファイルを開けません '%s': %w\n", filePath, err)
+		return nil, i18n.Errorf("ファイルを開けません '%s': %w\n", filePath, err)
+	}
+	return &Workbook{File: f, Path: filePath}, nil
+}
+
+// ReadExcelToSheet は指定されたExcelファイルを読み込み、Sheet構造体に変換します。
+// ファイルを開いてから閉じるまでを行うので、
+// 続けてローカル検証をする場合は OpenWorkbook と Workbook.ReadSheet を使います。
+func ReadExcelToSheet(filePath string) (sheet Sheet, err error) {
+	wb, err := OpenWorkbook(filePath)
+	if err != nil {
+		return
 	}
 	defer func() {
-		if err := f.Close(); err != nil {
-			err = fmt.Errorf("警告: ファイルクローズエラー '%s': %v\n", filePath, err)
+		if err := wb.Close(); err != nil {
+			err = i18n.Errorf("警告: ファイルクローズエラー '%s': %v\n", filePath, err)
 		}
 		// defer だからfmt.Printf()だけにすべき？
 	}()
+	return wb.ReadSheet()
+}
+
+// ReadSheet は開いているExcelファイルをSheet構造体に変換します。
+// Excelのレイアウトは提供された書き込みコードに基づいて定数で定義されたものを仮定しています。
+//
+// ヘッダーの日付と明細行のパースエラーはすべてのセルについて収集され、
+// errors.As で取り出せる ParseErrors として読み込めた範囲のsheetとともに返します。
+func (wb *Workbook) ReadSheet() (sheet Sheet, err error) {
+	f, filePath := wb.File, wb.Path
 
 	// 有効なファイルであることを確認できたら、
 	// Sheetを作成して、Header,Orderの読み込み
 	sheet = *New(filePath)
 	// 発注区分以外のヘッダー情報をExcelファイルから読み込み
-	if err = sheet.Header.read(f); err != nil {
-		err = fmt.Errorf("入力II読み込みエラー: '%s': %w\n", filePath, err)
+	// 日付の解釈エラーは明細の読み込みエラーとまとめて返す
+	var parseErrs ParseErrors
+	if err = sheet.Header.read(f); err != nil && !errors.As(err, &parseErrs) {
+		err = i18n.Errorf("入力II読み込みエラー: '%s': %w\n", filePath, err)
 		return
 	}
 
 	// オーダー情報をExcelファイルから読み込み
+	// 行のパースエラーがあっても読み込めた範囲のsheetを返す
 	if err = sheet.Orders.read(f); err != nil {
-		err = fmt.Errorf("入力I読み込みエラー: '%s': %w\n", filePath, err)
+		var rowErrs ParseErrors
+		errors.As(err, &rowErrs)
+		parseErrs = append(parseErrs, rowErrs...)
+	}
+
+	// 明細ごとの金額は印刷シートから読み込む
+	sheet.Orders.readPrices(f)
+
+	// ファイル名から発注区分が決まらなければ、シートの内容から推定する
+	sheet.OrderType = resolveOrderType(ParseFilename(filePath), &sheet)
+
+	if len(parseErrs) > 0 {
+		err = i18n.Errorf("入力I,II読み込みエラー: '%s': %w\n", filePath, parseErrs)
 		return
 	}
 
 	if len(sheet.Orders) == 0 {
-		err = fmt.Errorf("警告: ファイル '%s' のシート '%s' から明細データを読み取れませんでした。\n", filePath, orderSheetName)
+		err = i18n.Errorf("警告: ファイル '%s' のシート '%s' から明細データを読み取れませんでした。\n", filePath, orderSheetName)
 		return
 	}
 
@@ -106,10 +154,10 @@ func validateFile(f string) error {
 	// 渡されたファイルがディレクトリの場合は無視
 	fileInfo, err := os.Stat(f)
 	if err != nil {
-		return fmt.Errorf("ファイル情報読み込みエラー: %w\n", err)
+		return i18n.Errorf("ファイル情報読み込みエラー: %w\n", err)
 	}
 	if fileInfo.IsDir() {
-		return fmt.Errorf("%s はディレクトリです\n", f)
+		return i18n.Errorf("%s はディレクトリです\n", f)
 	}
 	return nil
 }
@@ -119,14 +167,20 @@ func validateFile(f string) error {
 func ActivateOrderSheet(filePath string) error {
 	f, err := excelize.OpenFile(filePath)
 	if err != nil {
-		return fmt.Errorf("ファイルを開けません '%s': %w\n", filePath, err)
+		return i18n.Errorf("ファイルを開けません '%s': %w\n", filePath, err)
 	}
 	defer f.Close()
+	wb := Workbook{File: f, Path: filePath}
+	return wb.ActivateOrderSheet()
+}
 
-	activeSheetIndex := f.GetActiveSheetIndex()
-	idx, err := f.GetSheetIndex(orderSheetName)
+// ActivateOrderSheet : 入力I以外がアクティブシートだったら
+// 入力Iをアクティブにして開いているファイルへ上書き保存する
+func (wb *Workbook) ActivateOrderSheet() error {
+	activeSheetIndex := wb.GetActiveSheetIndex()
+	idx, err := wb.GetSheetIndex(orderSheetName)
 	if err != nil || idx == -1 {
-		return fmt.Errorf("入力Iシートが見つかりません: %w\n", err)
+		return i18n.Errorf("入力Iシートが見つかりません: %w\n", err)
 	}
 
 	// 現在のアクティブシートが入力Iだったら何もせずに終了
@@ -136,11 +190,11 @@ func ActivateOrderSheet(filePath string) error {
 
 	// 入力I以外がアクティブシートだったら
 	// 入力Iをアクティブにして保存して終了
-	f.SetActiveSheet(idx)
+	wb.SetActiveSheet(idx)
 
-	if err := f.SaveAs(filePath); err != nil {
-		return fmt.Errorf("ファイル書き込みエラー: %w\n", err)
+	if err := wb.SaveAs(wb.Path); err != nil {
+		return i18n.Errorf("ファイル書き込みエラー: %w\n", err)
 	}
-	fmt.Printf("入力Iをアクティブにして%sへ上書き保存しました。", filePath)
+	fmt.Print(i18n.T("入力Iをアクティブにして%sへ上書き保存しました。", wb.Path))
 	return nil
 }
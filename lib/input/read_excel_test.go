@@ -1,8 +1,10 @@
 package input
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -32,17 +34,17 @@ func TestReadExcelToSheet_Success(t *testing.T) {
 		},
 		Orders: Orders{
 			{ // Row 2
-				Lv: 1, Pid: "PN-001", Name: "部品A", Type: "TypeX",
+				Row: 2, Lv: 1, Pid: "PN-001", Name: "部品A", Type: "TypeX",
 				Quantity: 10.5, Unit: "個", Deadline: "2023/11/15", Kenku: "受入",
 				Device: "装置1", Serial: "S001", Maker: "MakerX", Vendor: "VendorY", UnitPrice: 100.50,
 			},
 			{ // Row 3
-				Lv: 2, Pid: "PN-002", Name: "部品B", Type: "",
+				Row: 3, Lv: 2, Pid: "PN-002", Name: "部品B", Type: "",
 				Quantity: 5, Unit: "Set", Deadline: "", Kenku: "",
 				Device: "", Serial: "", Maker: "", Vendor: "", UnitPrice: 2500,
 			},
 			{ // Row 5
-				Lv: 0, Pid: "PN-003", Name: "部品C", Type: "",
+				Row: 5, Lv: 0, Pid: "PN-003", Name: "部品C", Type: "",
 				Quantity: 1, Unit: "", Deadline: "", Kenku: "",
 				Device: "", Serial: "", Maker: "", Vendor: "", UnitPrice: 0,
 			},
@@ -145,6 +147,68 @@ func TestReadExcelToSheet_InvalidNumberFormat_Orders(t *testing.T) {
 	t.Logf("期待通り明細数値エラーを検出: %v", err)
 }
 
+func TestReadExcelToSheet_CollectAllParseErrors(t *testing.T) {
+	testDir := "testdata_read"
+	testFile := createTestExcelFile(t, testDir, "20231027-multi_errors-read-K.xlsx", func(f *excelize.File) {
+		setValidLayout(f)
+		f.SetCellValue(orderSheetName, colQuantity+"2", "Not A Number") // 2行目の数量
+		f.SetCellValue(orderSheetName, colUnitPrice+"2", "Not A Price") // 2行目の予定単価
+		f.SetCellValue(orderSheetName, colLv+"3", "Lv?")                // 3行目のLv
+		f.SetCellValue(orderSheetName, colDeadlineO+"5", "来週中")         // 5行目の要望納期
+	})
+
+	sheet, err := ReadExcelToSheet(testFile)
+	if err == nil {
+		t.Fatal("明細行の数値変換エラーが検出されませんでした。")
+	}
+	var parseErrs ParseErrors
+	if !errors.As(err, &parseErrs) {
+		t.Fatalf("ParseErrorsが返されませんでした: %v", err)
+	}
+
+	wantCells := []string{"I2", "BG2", "A3", "J5"}
+	if len(parseErrs) != len(wantCells) {
+		t.Fatalf("エラー件数が異なります: 期待値=%d, 実際値=%d: %v", len(wantCells), len(parseErrs), parseErrs)
+	}
+	for i, want := range wantCells {
+		if got := parseErrs[i].Cell(); got != want {
+			t.Errorf("parseErrs[%d].Cell() = %q, want %q", i, got, want)
+		}
+	}
+
+	// エラーがあっても読み込めた範囲の明細は返される
+	if len(sheet.Orders) != 3 {
+		t.Errorf("部分的なSheetが返されませんでした: Orders=%d件", len(sheet.Orders))
+	}
+	if sheet.Orders[0].Pid != "PN-001" || sheet.Orders[0].Quantity != 0 {
+		t.Errorf("エラーのある項目はゼロ値で残るはずです: %+v", sheet.Orders[0])
+	}
+}
+
+func TestReadExcelToSheet_InvalidHeaderDate(t *testing.T) {
+	testDir := "testdata_read"
+	testFile := createTestExcelFile(t, testDir, "20231027-header_date-read-K.xlsx", func(f *excelize.File) {
+		setValidLayout(f)
+		f.SetCellValue(headerSheetName, requestDateCell, "令和7年4月1日") // 和暦は解釈できる
+		f.SetCellValue(headerSheetName, deadlineHCell, "年度末")        // 解釈できない
+	})
+
+	sheet, err := ReadExcelToSheet(testFile)
+	var parseErrs ParseErrors
+	if !errors.As(err, &parseErrs) {
+		t.Fatalf("ParseErrorsが返されませんでした: %v", err)
+	}
+	if len(parseErrs) != 1 || parseErrs[0].Sheet != headerSheetName || parseErrs[0].Cell() != deadlineHCell {
+		t.Fatalf("製番納期のセル番地を含むエラーが返されませんでした: %v", parseErrs)
+	}
+	if !strings.Contains(parseErrs[0].Error(), "入力Ⅱ D2: 製番納期") {
+		t.Errorf("エラーメッセージにセル番地が含まれていません: %v", parseErrs[0])
+	}
+	if sheet.RequestDate != "2025/04/01" {
+		t.Errorf("RequestDate = %q, want %q", sheet.RequestDate, "2025/04/01")
+	}
+}
+
 // expectedSheet.Header.ProjectID の期待値を修正
 func TestReadExcelToSheet_EmptySheet(t *testing.T) {
 	testDir := "testdata_read"
@@ -271,3 +335,72 @@ func TestActivateOrderSheet(t *testing.T) {
 		})
 	}
 }
+
+// setLargeLayout は100行の明細と印刷シートの合計行を持つレイアウトを設定します。
+func setLargeLayout(f *excelize.File) {
+	setValidLayout(f)
+	for r := ordersStartRow; r < ordersStartRow+100; r++ {
+		row := strconv.Itoa(r)
+		f.SetCellValue(orderSheetName, colPid+row, fmt.Sprintf("PN-%03d", r))
+		f.SetCellValue(orderSheetName, colName+row, "部品")
+		f.SetCellValue(orderSheetName, colQuantity+row, 1)
+		f.SetCellValue(orderSheetName, colDeadlineO+row, "2023/11/15")
+		f.SetCellValue(orderSheetName, colUnitPrice+row, 100)
+	}
+	f.SetCellValue(printSheetNameDefault, "AU113", "合計")
+}
+
+// BenchmarkLocalChecks は大量のファイルについて、読み込みとローカル検証の
+// ファイルI/Oを比較します。
+//
+//	shared: 1回だけ開いたWorkbookを読み込みとすべてのローカル検証で共有する
+//	reopen: 読み込みと検証ごとにファイルを開き直す
+func BenchmarkLocalChecks(b *testing.B) {
+	const batchSize = 50
+	files := make([]string, batchSize)
+	for i := range files {
+		name := fmt.Sprintf("20231027-bench%02d-read-K.xlsx", i)
+		files[i] = createTestExcelFile(b, "testdata_bench", name, setLargeLayout)
+	}
+	checks := []func(wb *Workbook){
+		func(wb *Workbook) { _ = validateExcelSums(wb.File, formulaCacheTolerance) },
+		func(wb *Workbook) { _ = validateHiddenColumns(wb.File) },
+		func(wb *Workbook) { _ = validateFormulaCache(wb.File) },
+	}
+
+	b.Run("shared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, path := range files {
+				wb, err := OpenWorkbook(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := wb.ReadSheet(); err != nil {
+					b.Fatal(err)
+				}
+				for _, check := range checks {
+					check(wb)
+				}
+				wb.Close()
+			}
+		}
+	})
+
+	b.Run("reopen", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, path := range files {
+				if _, err := ReadExcelToSheet(path); err != nil {
+					b.Fatal(err)
+				}
+				for _, check := range checks {
+					wb, err := OpenWorkbook(path)
+					if err != nil {
+						b.Fatal(err)
+					}
+					check(wb)
+					wb.Close()
+				}
+			}
+		}
+	})
+}
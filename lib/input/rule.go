@@ -0,0 +1,237 @@
+package input
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/config"
+	"pncheck/lib/i18n"
+)
+
+// Severity : ローカル検証で見つかった問題の重大度
+type Severity int
+
+const (
+	severityUnset   Severity = iota // ルールの重大度を使う
+	SeverityInfo                    // 参考情報。ステータスに影響しない
+	SeverityWarning                 // Warning (300)
+	SeverityError                   // Error (400)
+	SeverityFatal                   // Fatal (500)
+)
+
+// severityNames : 設定ファイルとレポートで使う重大度の名前
+var severityNames = map[Severity]string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+	SeverityFatal:   "fatal",
+}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// ParseSeverity は info, warning, error, fatal のいずれかを重大度に変換します。
+func ParseSeverity(s string) (Severity, error) {
+	for sev, name := range severityNames {
+		if name == s {
+			return sev, nil
+		}
+	}
+	return severityUnset, i18n.Errorf("不明な重大度 '%s'", s)
+}
+
+// StatusCode : 重大度に対応するレポートのステータスコード
+// Infoは成功(200)として扱う
+func (s Severity) StatusCode() int {
+	if s <= SeverityInfo {
+		return 200
+	}
+	return 100 + int(s)*100
+}
+
+// Finding : ルールが見つけた問題1件
+type Finding struct {
+	RuleID   string   // 問題を見つけたルールのID
+	Severity Severity // 重大度。空ならEngineがルールの重大度を設定する
+	Message  string   // レポートに表示する説明
+	Sheet    string   // 問題のあるシート名 (わかる場合のみ)
+	Row      int      // 問題のある行番号 (わかる場合のみ)
+	Cell     string   // 問題のあるセル番地 (わかる場合のみ)
+}
+
+// String : レポート表示用の文字列
+// 設定ファイルで重大度を変更できるよう、ルールIDを添える
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s] %s", f.RuleID, f.Message)
+}
+
+// Column : Cellの列 (例: "AY15" → "AY")。セル番地がなければ空文字
+func (f Finding) Column() string {
+	col, _, err := excelize.SplitCellName(f.Cell)
+	if err != nil {
+		return ""
+	}
+	return col
+}
+
+// Rule : ローカル検証ルール
+type Rule interface {
+	ID() string                // 設定ファイルで指定するルールID (例: sort-order)
+	Description() string       // ルールの説明
+	DefaultSeverity() Severity // 設定ファイルで変更しない場合の重大度
+	Check(wb *Workbook, sheet *Sheet) []Finding
+}
+
+// RuleFactory : 設定を受け取ってルールを作る関数
+// 設定によって内容や個数が変わるルールもあるため、複数のルールを返せる
+// 設定からルールを作れなければエラーを返す
+type RuleFactory func(cfg *config.Config) ([]Rule, error)
+
+// registry : Register で登録されたルール
+var registry []RuleFactory
+
+// Register はEngineが実行するルールを登録します。
+// 組み込みのルールは rules.go の init で登録します。
+func Register(factories ...RuleFactory) {
+	registry = append(registry, factories...)
+}
+
+// ruleFunc : 関数をRuleとして扱うためのアダプター
+//
+// description は日本語で書き、表示するときに翻訳する。
+// static のルールは init で作られ、表示する言語が決まる前に説明を翻訳できないため。
+type ruleFunc struct {
+	id          string
+	description string
+	severity    Severity
+	check       func(wb *Workbook, sheet *Sheet) []Finding
+}
+
+func (r ruleFunc) ID() string                                 { return r.id }
+func (r ruleFunc) Description() string                        { return i18n.T(r.description) }
+func (r ruleFunc) DefaultSeverity() Severity                  { return r.severity }
+func (r ruleFunc) Check(wb *Workbook, sheet *Sheet) []Finding { return r.check(wb, sheet) }
+
+// static : 設定に依存しないルールのRuleFactory
+func static(rules ...Rule) RuleFactory {
+	return func(*config.Config) ([]Rule, error) { return rules, nil }
+}
+
+// Engine : 設定を反映したローカル検証ルールの集合
+type Engine struct {
+	all      []Rule              // 登録されたすべてのルール
+	rules    []Rule              // 有効なルール
+	severity map[string]Severity // ルールIDごとの重大度。無効なルールは含まない
+}
+
+// NewEngine は登録されたルールに設定の有効・無効と重大度を反映したEngineを作成します。
+// 設定ファイルに存在しないルールIDが書かれていればエラーを返します。
+func NewEngine(cfg *config.Config) (*Engine, error) {
+	e := &Engine{severity: make(map[string]Severity)}
+	known := make(map[string]bool)
+	for _, factory := range registry {
+		rules, err := factory(cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rules {
+			if known[r.ID()] {
+				return nil, i18n.Errorf("ルールID '%s' が重複しています", r.ID())
+			}
+			known[r.ID()] = true
+			e.all = append(e.all, r)
+			if !cfg.RuleEnabled(r.ID()) {
+				continue
+			}
+			sev := r.DefaultSeverity()
+			if s := cfg.Rules[r.ID()].Severity; s != "" {
+				var err error
+				if sev, err = ParseSeverity(s); err != nil {
+					return nil, fmt.Errorf("rules.%s: %w", r.ID(), err)
+				}
+			}
+			e.rules = append(e.rules, r)
+			e.severity[r.ID()] = sev
+		}
+	}
+	for id := range cfg.Rules {
+		if !known[id] {
+			return nil, i18n.Errorf("rules: 不明なルールID '%s'", id)
+		}
+	}
+	return e, nil
+}
+
+// Rules は有効なルールを返します。
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Severity は設定を反映したルールの重大度を返します。
+func (e *Engine) Severity(id string) Severity {
+	return e.severity[id]
+}
+
+// Run は有効なすべてのルールを実行し、見つかった問題を返します。
+// Findingの重大度が空ならルールの重大度を設定します。
+func (e *Engine) Run(wb *Workbook, sheet *Sheet) (findings []Finding) {
+	for _, r := range e.rules {
+		for _, f := range r.Check(wb, sheet) {
+			f.RuleID = r.ID()
+			findings = append(findings, f)
+		}
+	}
+	return e.Grade(findings)
+}
+
+// Grade はFindingにルールIDの設定を反映します。
+// 無効なルールのFindingは取り除き、重大度が空ならルールの重大度を設定します。
+// ファイルをまたぐ確認のように Run の外で見つけたFindingにも使います。
+func (e *Engine) Grade(findings []Finding) (graded []Finding) {
+	for _, f := range findings {
+		sev, ok := e.severity[f.RuleID]
+		if !ok {
+			continue
+		}
+		if f.Severity == severityUnset {
+			f.Severity = sev
+		}
+		graded = append(graded, f)
+	}
+	return
+}
+
+// Describe は登録されたすべてのルールのID、重大度、説明の一覧を返します。
+// 設定で無効にしたルールも、有効に戻せるよう重大度の代わりに disabled と表示します。
+func (e *Engine) Describe() string {
+	var b strings.Builder
+	for _, r := range e.all {
+		sev, ok := e.severity[r.ID()]
+		state := sev.String()
+		if !ok {
+			state = "disabled"
+		}
+		fmt.Fprintf(&b, "%-16s %-8s %s\n", r.ID(), state, r.Description())
+	}
+	return b.String()
+}
+
+// MaxSeverity はFindingの中で最も重い重大度を返します。
+func MaxSeverity(findings []Finding) Severity {
+	worst := severityUnset
+	for _, f := range findings {
+		worst = max(worst, f.Severity)
+	}
+	return worst
+}
+
+// SortFindings は重大度の重い順に並べ替えます。同じ重大度は検出順を保ちます。
+func SortFindings(findings []Finding) {
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return int(b.Severity) - int(a.Severity)
+	})
+}
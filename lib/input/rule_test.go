@@ -0,0 +1,116 @@
+package input
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/config"
+)
+
+func TestNewEngine(t *testing.T) {
+	disabled := false
+	// 組部品でない製番で、要望納期の順に並んでいない明細
+	sheet := &Sheet{
+		Header: Header{ProjectID: "123456789000", RequestDate: "2020/01/01"},
+		Orders: Orders{
+			{Pid: "A", Deadline: "2025/02/01", Row: 2},
+			{Pid: "B", Deadline: "2025/01/01", Row: 3},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		rules    map[string]config.Rule
+		wantErr  bool
+		wantSort Severity // sort-order の重大度。severityUnset なら検出されない
+	}{
+		{"既定値", nil, false, SeverityFatal},
+		{"重大度の変更", map[string]config.Rule{"sort-order": {Severity: "warning"}}, false, SeverityWarning},
+		{"ルールの無効化", map[string]config.Rule{"sort-order": {Enabled: &disabled}}, false, severityUnset},
+		{"不明なルールID", map[string]config.Rule{"no-such-rule": {}}, true, severityUnset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Rules = tt.rules
+			engine, err := NewEngine(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewEngine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			wb := &Workbook{File: excelize.NewFile(), Path: "20200101-123456789000-001-K.xlsx"}
+			defer wb.Close()
+			got := severityUnset
+			for _, f := range engine.Run(wb, sheet) {
+				if f.RuleID == "sort-order" {
+					got = f.Severity
+				}
+			}
+			if got != tt.wantSort {
+				t.Errorf("sort-order の重大度 = %v, want %v", got, tt.wantSort)
+			}
+		})
+	}
+}
+
+func TestEngine_Describe(t *testing.T) {
+	disabled := false
+	cfg := config.Default()
+	cfg.Rules = map[string]config.Rule{
+		"sort-order": {Enabled: &disabled},
+		"unit":       {Severity: "error"},
+	}
+	engine, err := NewEngine(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(engine.Describe(), "\n")
+	for _, want := range []string{"sort-order       disabled", "unit             error", "hidden-column    fatal"} {
+		if !slices.ContainsFunc(lines, func(l string) bool { return strings.HasPrefix(l, want) }) {
+			t.Errorf("Describe() に %q の行がありません:\n%s", want, engine.Describe())
+		}
+	}
+}
+
+func TestSeverity_StatusCode(t *testing.T) {
+	tests := []struct {
+		severity Severity
+		want     int
+	}{
+		{severityUnset, 200},
+		{SeverityInfo, 200},
+		{SeverityWarning, 300},
+		{SeverityError, 400},
+		{SeverityFatal, 500},
+	}
+	for _, tt := range tests {
+		if got := tt.severity.StatusCode(); got != tt.want {
+			t.Errorf("%v.StatusCode() = %d, want %d", tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestMaxSeverity(t *testing.T) {
+	findings := []Finding{
+		{Severity: SeverityWarning},
+		{Severity: SeverityFatal},
+		{Severity: SeverityInfo},
+	}
+	if got := MaxSeverity(findings); got != SeverityFatal {
+		t.Errorf("MaxSeverity() = %v, want %v", got, SeverityFatal)
+	}
+	SortFindings(findings)
+	if findings[0].Severity != SeverityFatal || findings[2].Severity != SeverityInfo {
+		t.Errorf("SortFindings() = %v", findings)
+	}
+	if got := MaxSeverity(nil); got.StatusCode() != 200 {
+		t.Errorf("MaxSeverity(nil).StatusCode() = %d, want 200", got.StatusCode())
+	}
+}
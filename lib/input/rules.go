@@ -0,0 +1,90 @@
+package input
+
+import (
+	"fmt"
+
+	"pncheck/lib/i18n"
+)
+
+// 組み込みのローカル検証ルール
+//
+// 既定の重大度は、PNSearchへ正しく登録できない問題をFatal、
+// 発注区分と矛盾する入力のように修正が必要な問題をError、
+// 登録はできるが確認してほしい問題をWarningとしている。
+// 重大度は設定ファイルの rules で変更できる。
+func init() {
+	Register(static(
+		ruleFunc{
+			id:          "sheet-version",
+			description: "要求票の版番号がサーバーの最新版と一致すること",
+			severity:    SeverityFatal,
+			check: func(_ *Workbook, sheet *Sheet) []Finding {
+				return errorFinding(i18n.T("要求票の版番号の確認: "), validateSheetVersion(sheet.Version))
+			},
+		},
+		ruleFunc{
+			id:          "future-request",
+			description: "要求年月日が未来の日付でないこと",
+			severity:    SeverityFatal,
+			check: func(_ *Workbook, sheet *Sheet) []Finding {
+				return errorFinding("", validateFutureRequest(sheet.RequestDate))
+			},
+		},
+		ruleFunc{
+			id:          "sort-order",
+			description: "入力Ⅰが要望納期と品番の順に並んでいること (組部品を除く)",
+			severity:    SeverityFatal,
+			check: func(_ *Workbook, sheet *Sheet) []Finding {
+				return sortValidation(sheet)
+			},
+		},
+		ruleFunc{
+			id:          "order-type-fields",
+			description: "発注区分ごとに必要な項目(外注の要望先と予定単価、購入のメーカと型式など)が入力されていること",
+			severity:    SeverityError,
+			check: func(_ *Workbook, sheet *Sheet) []Finding {
+				return validateOrderTypeFields(sheet)
+			},
+		},
+		ruleFunc{
+			id:          "formula-cache",
+			description: "数式の保存値が再計算値と一致すること",
+			severity:    SeverityWarning,
+			check: func(wb *Workbook, _ *Sheet) []Finding {
+				return messageFindings(validateFormulaCache(wb.File))
+			},
+		},
+		ruleFunc{
+			id:          "filename",
+			description: "ファイル名が命名規則に従い、要求年月日と号機が要求票と一致すること",
+			severity:    SeverityWarning,
+			check: func(wb *Workbook, sheet *Sheet) []Finding {
+				return messageFindings(validateFilename(ParseFilename(wb.Path), sheet))
+			},
+		},
+		ruleFunc{
+			id:          "order-type",
+			description: "ファイル名の発注区分が要求票の内容と矛盾しないこと",
+			severity:    SeverityWarning,
+			check: func(wb *Workbook, sheet *Sheet) []Finding {
+				return messageFindings(validateOrderType(ParseFilename(wb.Path), sheet))
+			},
+		},
+	))
+}
+
+// errorFinding : 検証関数のエラーをFindingにする。エラーがなければnil
+func errorFinding(prefix string, err error) []Finding {
+	if err == nil {
+		return nil
+	}
+	return []Finding{{Message: fmt.Sprintf("%s%s", prefix, err)}}
+}
+
+// messageFindings : 検証関数の警告文をFindingにする
+func messageFindings(msgs []string) (findings []Finding) {
+	for _, m := range msgs {
+		findings = append(findings, Finding{Message: m})
+	}
+	return
+}
@@ -7,15 +7,17 @@ import (
 	"io"
 	"log"
 	"log/slog"
-	"math"
 	"net/http"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/i18n"
 )
 
 const (
@@ -68,8 +70,6 @@ const (
 var (
 	// API通信のデフォルトタイムアウト
 	defaultTimeout = 30 * time.Second
-	// PNSearch規格外の日付文字列
-	dateLayoutSub = []string{"01-02-06", "2006/1/2", "1/2/2006"}
 )
 
 type (
@@ -104,7 +104,7 @@ type (
 		Pid       string  `json:"品番"`
 		Name      string  `json:"品名"`
 		Type      string  `json:"型式"`
-		StockNum  float64 // バックエンド側で在庫数はサーチできるのでPOST不要
+		StockNum  float64 `json:"-"` // バックエンド側で在庫数はサーチできるのでPOST不要
 		Quantity  float64 `json:"数量"`
 		Unit      string  `json:"単位"`
 		Deadline  string  `json:"要望納期"`
@@ -114,7 +114,11 @@ type (
 		Maker     string  `json:"メーカ"`
 		Vendor    string  `json:"要望先"`
 		UnitPrice float64 `json:"予定単価"`
-		Price     float64 // UnitPriceとQuantityの積なのでPOST不要
+		Price     float64 `json:"-"` // UnitPriceとQuantityの積なのでPOST不要
+		Row       int     `json:"-"` // 入力Ⅰ上の行番号 エラー表示用なのでPOST不要
+
+		priceSheet string // Priceを読み込んだ印刷シート (例: "10品目用")。読み込めなければ空文字
+		priceCell  string // Priceを読み込んだ印刷シートのセル (例: "AY13")
 	}
 	Orders []Order
 	// Sheet : JSONでPOSTされる要求票構造体
@@ -132,12 +136,13 @@ type (
 
 // New はファイルパスfからシート構造の初期値を出力する。
 func New(f string) *Sheet {
+	fn := ParseFilename(f)
 	return &Sheet{
 		Config: Config{true, true, true, true}, // エラーチェックなどのPNSearchの機能をすべてtrueにする
 		Header: Header{
-			FileName:  newFileName(f),    // _pncheckを付与
-			OrderType: parseOrderType(f), // 発注区分をファイル名から分類
-			Serial:    parseSerial(f),    // 号機をファイル名から取得
+			FileName:  newFileName(f), // _pncheckを付与
+			OrderType: fn.OrderType(), // 発注区分をファイル名から分類
+			Serial:    fn.Serial,      // 号機をファイル名から取得
 		},
 		Orders: make(Orders, 0),
 	}
@@ -151,18 +156,8 @@ func newFileName(f string) string {
 	return strings.TrimSuffix(base, ext) + "_pncheck" + ext
 }
 
-// parseSerial はファイルパスからSerialを読み込む
-func parseSerial(f string) string {
-	base, ext := filepath.Base(f), filepath.Ext(f)
-	noext := strings.TrimSuffix(base, ext)
-	fields := strings.Split(noext, "-")
-	if len(fields) < 3 {
-		return ""
-	}
-	return fields[2]
-}
-
 // Header.read : 入力II からヘッダー(Header)の読み込み
+// 日付の解釈エラーは読み込みを止めずにParseErrorsとして返す
 func (h *Header) read(f *excelize.File) error {
 	// 製番 (親番のみ読み取り)
 	parentID := getCellValue(f, headerSheetName, projectIDCell)
@@ -173,17 +168,24 @@ func (h *Header) read(f *excelize.File) error {
 
 	h.ProjectName = getCellValue(f, headerSheetName, projectNameCell)
 	// 要求年月日と製番納期は DateLayout の型あるいは空欄に直す
-	d := getCellValue(f, headerSheetName, requestDateCell)
-	if dd, err := parseDateSafe(d); err != nil {
-		return err
-	} else {
-		h.RequestDate = dd
-	}
-	d = getCellValue(f, headerSheetName, deadlineHCell)
-	if dd, err := parseDateSafe(d); err != nil {
-		return err
-	} else {
-		h.Deadline = dd
+	// 解釈できない日付はセル番地とともにParseErrorsとして返す
+	var errs ParseErrors
+	date1904 := isDate1904(f)
+	for _, c := range []struct {
+		cell, field string
+		dst         *string
+	}{
+		{requestDateCell, "要求年月日", &h.RequestDate},
+		{deadlineHCell, "製番納期", &h.Deadline},
+	} {
+		d := getCellValue(f, headerSheetName, c.cell)
+		dd, err := parseDateSafe(d, date1904)
+		if err != nil {
+			errs = append(errs, newCellError(headerSheetName, c.cell, c.field, d,
+				i18n.T("正しい日付型%sではありません", DateLayout), err))
+			continue
+		}
+		*c.dst = dd
 	}
 
 	h.Note = getCellValue(f, headerSheetName, noteCell)
@@ -198,7 +200,7 @@ func (h *Header) read(f *excelize.File) error {
 	ver, err := f.GetCellValue(printSheetName, versionCell)
 	localSheetVersion := strings.TrimSpace(ver)
 	if err != nil || localSheetVersion == "" {
-		return fmt.Errorf(
+		return i18n.Errorf(
 			"要求票ファイルからバージョン情報を読み取れませんでした。"+
 				"セル'%s' が空か存在しない可能性があります。",
 			versionCell,
@@ -215,6 +217,9 @@ func (h *Header) read(f *excelize.File) error {
 	// この中から数値だけ抜き出して、string型で取り出す処理
 	re := regexp.MustCompile(`\d+`)
 	h.Remark = re.FindString(s)
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
@@ -254,61 +259,87 @@ func parseFloatSafe(s string) (float64, error) {
 	return strconv.ParseFloat(s, 64)
 }
 
-// PNSearchが求める日付の文字列型を修正して返す
-//
-// まずはDateLayout, dateLayoutSub に定めた文字列型として解釈し、
-// 失敗したらExcel日付型として解釈する。
-//
-// パースできないような文字列や
-// 空欄はPNSearch側でエラーにならないような処理となっているため
-// 影響がないと判断すれば空欄にして返すのが安全
-func parseDateSafe(s string) (string, error) {
-	switch s { // 棒線や空欄ならそのまま返す
-	case "", "‐", "-", "－", "―", "ー":
-		return "", nil
-	}
-
-	_, err := time.Parse(DateLayout, s)
-	if err == nil { // dataLayoutでパースできなければそのまま返す
-		return s, nil
-	}
-	// Excel標準の型でもパースできなければエラー
-	for _, layoutSub := range dateLayoutSub {
-		var t time.Time
-		t, err = time.Parse(layoutSub, s)
-		// fmt.Fprintln(os.Stderr, "[DEBUG]", "parse success", t)
-		// パースに成功したらPNSearch標準の文字列型で返す
-
-		// DEBUG
-		// fmt.Fprintln(os.Stderr,
-		// 	fmt.Sprintf("[DEBUG]%sで%sをParseした結果: %s",
-		// 		layoutSub, s, t))
-		if err == nil {
-			return t.Format(DateLayout), nil
-		}
+// CellError : セル1つ分の読み込みエラー
+type CellError struct {
+	Sheet  string // シート名
+	Row    int    // Excel上の行番号
+	Column string // 列名 (例: "I")
+	Field  string // 項目名 (例: "数量")
+	Value  string // セルに入力されていた文字列
+	Reason string // エラーの概要 (例: "数値ではありません")
+	Err    error  // 内部エラーの保持
+}
 
+// newCellError : セル番地cellの読み込みエラーを作成する
+func newCellError(sheetName, cell, field, value, reason string, err error) *CellError {
+	col, row, _ := excelize.SplitCellName(cell)
+	return &CellError{
+		Sheet:  sheetName,
+		Row:    row,
+		Column: col,
+		Field:  field,
+		Value:  value,
+		Reason: reason,
+		Err:    err,
 	}
+}
+
+// Error errorインターフェースを満たすための実装
+func (e *CellError) Error() string {
+	if e.Sheet == orderSheetName {
+		return i18n.T("明細(%s) %d行目: %s(%s)が%s: %v",
+			e.Sheet, e.Row, e.Field, e.Column, e.Reason, e.Err)
+	}
+	return i18n.T("%s %s: %sが%s: %v", e.Sheet, e.Cell(), e.Field, e.Reason, e.Err)
+}
+
+// Unwrap : エラーチェーンをサポートするための実装
+func (e *CellError) Unwrap() error {
+	return e.Err
+}
+
+// Cell : エラーが発生したセル番地 (例: "I2")
+func (e *CellError) Cell() string {
+	return e.Column + strconv.Itoa(e.Row)
+}
 
-	// 文字列型で読み込めなければExcelTime(1900年1月1日が0)
-	if v, err := strconv.ParseFloat(s, 64); err == nil && v > 0 {
-		t := excelTimeToGoTime(v)
-		return t.Format(DateLayout), nil
+// ParseErrors : ヘッダーと明細行の読み込みで発生したエラーの一覧
+// 最初のエラーで読み込みを止めず、すべての行のエラーを保持する
+type ParseErrors []*CellError
+
+// Error errorインターフェースを満たすための実装
+func (errs ParseErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
 	}
+	return strings.Join(msgs, "\n")
+}
 
-	return s, err
+// Covers はfが読み込めなかったセルへの指摘であればtrueを返します。
+// 読み込めた範囲のSheetで検証したとき、ゼロ値のまま残したセルへの指摘を除くために使います。
+func (errs ParseErrors) Covers(f Finding) bool {
+	return f.Cell != "" && slices.ContainsFunc(errs, func(e *CellError) bool {
+		return e.Sheet == f.Sheet && e.Cell() == f.Cell
+	})
 }
 
 // processOrderRow : 1行分のデータをOrder構造体に変換
+// 変換できなかった項目はゼロ値のまま残し、エラーをすべて返す
+// date1904 はワークブックの日付システムで、Orders.read で1回だけ調べる
 func processOrderRow(
 	f *excelize.File,
 	r int,
 	rowPid, rowName, rowQuantityStr string,
-) (order Order, err error) {
+	date1904 bool,
+) (order Order, errs ParseErrors) {
+	cell := func(col string) string { return col + strconv.Itoa(r) }
+	var err error
+	order.Row = r
 	lvStr := getCellValue(f, orderSheetName, colLv+strconv.Itoa(r))
 	order.Lv, err = parseIntSafe(lvStr)
 	if err != nil {
-		err = fmt.Errorf("明細(%s) %d行目: Lv(%s)が数値ではありません: %w", orderSheetName, r, colLv, err)
-		return
+		errs = append(errs, newCellError(orderSheetName, cell(colLv), "Lv", lvStr, i18n.T("数値ではありません"), err))
 	}
 
 	order.Pid = rowPid
@@ -317,19 +348,16 @@ func processOrderRow(
 
 	// 数量をパース
 	order.Quantity, err = parseFloatSafe(rowQuantityStr)
-	if err != nil && rowQuantityStr != "" {
-		err = fmt.Errorf("明細(%s) %d行目: 数量(%s)が数値ではありません: %w", orderSheetName, r, colQuantity, err)
-		return
+	if err != nil {
+		errs = append(errs, newCellError(orderSheetName, cell(colQuantity), "数量", rowQuantityStr, i18n.T("数値ではありません"), err))
 	}
 
 	order.Unit = getCellValue(f, orderSheetName, colUnit+strconv.Itoa(r))
 	// 要望納期は DateLayout の型あるいは空欄に直す
 	d := getCellValue(f, orderSheetName, colDeadlineO+strconv.Itoa(r))
-	if dd, dateErr := parseDateSafe(d); err != nil {
-		err = fmt.Errorf(
-			"明細(%s) %d行目: 数量(%s)が正しい日付型%sではありません: %w",
-			orderSheetName, r, colDeadlineO, DateLayout, dateErr)
-		return
+	if dd, dateErr := parseDateSafe(d, date1904); dateErr != nil {
+		errs = append(errs, newCellError(orderSheetName, cell(colDeadlineO), "要望納期", d,
+			i18n.T("正しい日付型%sではありません", DateLayout), dateErr))
 	} else {
 		order.Deadline = dd
 	}
@@ -343,14 +371,18 @@ func processOrderRow(
 	unitPriceStr := getCellValue(f, orderSheetName, colUnitPrice+strconv.Itoa(r))
 	order.UnitPrice, err = parseFloatSafe(unitPriceStr)
 	if err != nil {
-		err = fmt.Errorf("明細(%s) %d行目: 予定単価(%s)が数値ではありません: %w", orderSheetName, r, colUnitPrice, err)
-		return
+		errs = append(errs, newCellError(orderSheetName, cell(colUnitPrice), "予定単価", unitPriceStr, i18n.T("数値ではありません"), err))
 	}
 	return
 }
 
 // read : 入力Ⅰから明細行 (Orders) の読み込み
+//
+// 行のパースエラーがあっても読み込みを続け、
+// 読み込めた範囲のOrdersと、すべての行のエラーをParseErrorsとして返す
 func (o *Orders) read(f *excelize.File) error {
+	var errs ParseErrors
+	date1904 := isDate1904(f)
 	emptyRowCount := 0
 	for r := ordersStartRow; ; r++ {
 		// 1行分のデータを読み込む (主要な列が空かチェック - 品番, 品名, 数量)
@@ -368,17 +400,27 @@ func (o *Orders) read(f *excelize.File) error {
 		}
 		emptyRowCount = 0 // データがあればカウンタリセット
 
-		order, err := processOrderRow(f, r, rowPid, rowName, rowQuantityStr)
-		if err != nil {
-			return err
-		}
+		order, rowErrs := processOrderRow(f, r, rowPid, rowName, rowQuantityStr, date1904)
+		errs = append(errs, rowErrs...)
 
 		// 読み取ったOrderをスライスに追加
 		*o = append(*o, order)
 	}
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
+// OrderLocation : i番目(0始まり)の明細のシート名と行番号。範囲外ならokはfalse
+// PNSearchが返すErrorRecordのIndexを入力Ⅰの行に対応させるために使う。
+func (sheet *Sheet) OrderLocation(i int) (sheetName string, row int, ok bool) {
+	if i < 0 || i >= len(sheet.Orders) {
+		return "", 0, false
+	}
+	return orderSheetName, sheet.Orders[i].Row, true
+}
+
 // Sheet.Post() でサーバーへポスト
 // 戻り値はbody, code, error
 // code のデフォルト値は500
@@ -388,10 +430,10 @@ func (o *Orders) read(f *excelize.File) error {
 // ステータスコードが2xx以外でも、ボディがあれば読み込んで返します。
 func (sheet *Sheet) Post() (body []byte, statusCode int, err error) {
 	if ServerAddress == "" {
-		log.Fatalln(
+		log.Fatalln(i18n.T(
 			`APIサーバーアドレスが空です。ビルド時に設定する必要があります。
 $ go build -ldflags="-X pncheck/lib/input.ServerAddress=http://localhost:8080"`,
-		)
+		))
 	}
 
 	var apiURL = ServerAddress + apiEndpointPath
@@ -399,13 +441,13 @@ $ go build -ldflags="-X pncheck/lib/input.ServerAddress=http://localhost:8080"`,
 
 	jsonData, err := json.Marshal(sheet)
 	if err != nil {
-		err = fmt.Errorf("Sheet構造体のJSON変換に失敗しました: %w", err)
+		err = i18n.Errorf("Sheet構造体のJSON変換に失敗しました: %w", err)
 		return
 	}
 
 	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		err = fmt.Errorf("HTTPリクエストの作成に失敗しました: %w", err)
+		err = i18n.Errorf("HTTPリクエストの作成に失敗しました: %w", err)
 		return
 	}
 
@@ -414,7 +456,7 @@ $ go build -ldflags="-X pncheck/lib/input.ServerAddress=http://localhost:8080"`,
 	resp, err := client.Do(req)
 	if err != nil {
 		// 接続エラーなど、レスポンス自体が得られなかった場合
-		err = fmt.Errorf("APIへのリクエスト送信に失敗しました (%s): %w", apiURL, err)
+		err = i18n.Errorf("APIへのリクエスト送信に失敗しました (%s): %w", apiURL, err)
 		return
 	}
 	defer resp.Body.Close()
@@ -426,7 +468,7 @@ $ go build -ldflags="-X pncheck/lib/input.ServerAddress=http://localhost:8080"`,
 	body, err = io.ReadAll(resp.Body)
 	if err != nil {
 		// ボディ読み込み失敗は致命的エラー
-		err = fmt.Errorf("APIレスポンスボディの読み込みに失敗しました: %w", err)
+		err = i18n.Errorf("APIレスポンスボディの読み込みに失敗しました: %w", err)
 		return
 	}
 	return
@@ -478,7 +520,7 @@ func getFloatCellValue(f *excelize.File, sheetName, axis string) float64 {
 	val, err := parseFloatSafe(s)
 	if err != nil {
 		slog.Warn(
-			"セル値の数値変換に失敗しました。",
+			i18n.T("セル値の数値変換に失敗しました。"),
 			slog.String("sheet", sheetName),
 			slog.String("cell", axis),
 			slog.String("value", s),
@@ -496,16 +538,16 @@ func sumCellRange(f *excelize.File, sheetName, cellRange string) (float64, error
 	// セル範囲をパース (例: "A1:A10")
 	parts := strings.Split(cellRange, ":")
 	if len(parts) != 2 {
-		return 0.0, fmt.Errorf("無効なセル範囲形式: %s", cellRange)
+		return 0.0, i18n.Errorf("無効なセル範囲形式: %s", cellRange)
 	}
 
 	startColInt, startRowInt, err := excelize.CellNameToCoordinates(parts[0])
 	if err != nil {
-		return 0.0, fmt.Errorf("開始セル参照のパースに失敗しました: %w", err)
+		return 0.0, i18n.Errorf("開始セル参照のパースに失敗しました: %w", err)
 	}
 	endColInt, endRowInt, err := excelize.CellNameToCoordinates(parts[1])
 	if err != nil {
-		return 0.0, fmt.Errorf("終了セル参照のパースに失敗しました: %w", err)
+		return 0.0, i18n.Errorf("終了セル参照のパースに失敗しました: %w", err)
 	}
 
 	startCol := startColInt
@@ -515,14 +557,14 @@ func sumCellRange(f *excelize.File, sheetName, cellRange string) (float64, error
 
 	// 現在の要件では単一列の範囲のみを想定しているため、列が異なる場合はエラーとする
 	if startCol != endCol {
-		return 0.0, fmt.Errorf("複数列にまたがる範囲の合計はサポートしていません: %s", cellRange)
+		return 0.0, i18n.Errorf("複数列にまたがる範囲の合計はサポートしていません: %s", cellRange)
 	}
 
 	for r := startRow; r <= endRow; r++ {
 		cellAxis, err := excelize.CoordinatesToCellName(startCol, r)
 		if err != nil {
 			slog.Warn(
-				"セル座標から名前への変換に失敗しました。",
+				i18n.T("セル座標から名前への変換に失敗しました。"),
 				slog.Int("col", startCol),
 				slog.Int("row", r),
 				slog.String("error", err.Error()),
@@ -538,23 +580,3 @@ func sumCellRange(f *excelize.File, sheetName, cellRange string) (float64, error
 func BuildRequestURL(sha256 string) string {
 	return fmt.Sprintf("%s/index?hash=%s#requirement-tab", ServerAddress, sha256)
 }
-
-// Excelのシリアル値をGoの time.Time に変換するヘルパー関数
-// Excel Time型は整数部分が日数を、小数部分が時刻を表す
-func excelTimeToGoTime(excelSerialValue float64) time.Time {
-	// 1900年ベースの場合の起点は 1900/1/1 (シリアル値 1)
-	excelSerialValue -= 2.0
-	// 日数部分の計算
-	days := math.Floor(excelSerialValue)
-	// 秒数の計算 (24時間 * 3600秒/時間 = 86400秒/日)
-	seconds := math.Round((excelSerialValue - days) * 86400.0)
-
-	// 基準日 (1900年1月1日)
-	// Goの time.Time は1900年1月1日 00:00:00 (JST) から日数を加算する
-	baseTime := time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
-
-	// 基準日から日数と秒数を加算して返す
-	t := baseTime.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
-
-	return t
-}
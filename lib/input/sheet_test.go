@@ -1,7 +1,9 @@
 package input
 
 import (
+	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/xuri/excelize/v2"
@@ -28,6 +30,18 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestOrder_MarshalJSON(t *testing.T) {
+	b, err := json.Marshal(Order{Pid: "PN-1", StockNum: 5, Price: 1200, Row: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"StockNum", "Price", "Row"} {
+		if strings.Contains(string(b), `"`+key+`"`) {
+			t.Errorf("POSTしない %s が含まれています: %s", key, b)
+		}
+	}
+}
+
 // TestGetLastRemarkValue tests the getLastRemarkValue function
 func TestGetLastRemarkValue(t *testing.T) {
 	testDir := "testdata_sheet_remark"
@@ -188,46 +202,3 @@ func TestNewFileName(t *testing.T) {
 		})
 	}
 }
-
-func TestParseSerial(t *testing.T) {
-	tests := []struct {
-		name     string
-		filePath string
-		expected string
-	}{
-		{
-			name:     "valid file name",
-			filePath: "20231027-12345678-S001-K.xlsx",
-			expected: "S001",
-		},
-		{
-			name:     "short file name length 2",
-			filePath: "20231027-12345678.xlsx",
-			expected: "",
-		},
-		{
-			name:     "short file name length 3",
-			filePath: "20251114-000080010742-TBP.xlsx",
-			expected: "TBP",
-		},
-		{
-			name:     "empty file name",
-			filePath: "",
-			expected: "",
-		},
-		{
-			name:     "file name with different delimiters",
-			filePath: "20231027_12345678_S001_K.xlsx",
-			expected: "", // ハイフン区切りではないため
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			actua := parseSerial(tt.filePath)
-			if actua != tt.expected {
-				t.Errorf("parseSerial(%q) = %q, want %q", tt.filePath, actua, tt.expected)
-			}
-		})
-	}
-}
@@ -0,0 +1,123 @@
+package input
+
+import (
+	"log/slog"
+	"math"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/config"
+	"pncheck/lib/i18n"
+)
+
+func init() {
+	Register(sumRules)
+}
+
+// sumRules : 設定の許容誤差を反映した合計金額のルールを作る
+func sumRules(cfg *config.Config) ([]Rule, error) {
+	tolerance := cfg.Sum.Tolerance
+	return []Rule{
+		ruleFunc{
+			id:          "excel-sum",
+			description: "各シートの合計金額が明細の金額の合計と一致すること",
+			severity:    SeverityFatal,
+			check: func(wb *Workbook, _ *Sheet) []Finding {
+				return validateExcelSums(wb.File, tolerance)
+			},
+		},
+	}, nil
+}
+
+// sumCheck : 1つのシートの合計金額の確認結果
+type sumCheck struct {
+	sheet  string
+	config sheetValidationConfig
+	sum    float64 // 明細の範囲の合計
+	upper  float64 // 上段の合計セル(印刷シートはAX7, 入力ⅡはO7)の値
+	bottom float64 // 下段の合計行の値
+	err    error   // 合計を計算できなかった理由
+}
+
+// ok : 上段と下段の合計がどちらも範囲の合計と許容誤差内で一致すればtrue
+func (c sumCheck) ok(tolerance float64) bool {
+	return c.err == nil &&
+		math.Abs(c.sum-c.upper) <= tolerance &&
+		math.Abs(c.sum-c.bottom) <= tolerance
+}
+
+// checkSheetSum : シートの範囲の合計と上下の合計セルの値を読み込む
+func checkSheetSum(f *excelize.File, sheetName string) (c sumCheck) {
+	c.sheet = sheetName
+	config, err := getSheetValidationConfig(f, sheetName)
+	if err != nil {
+		c.err = i18n.Errorf("合計計算設定エラー: %w", err)
+		return
+	}
+	c.config = config
+	c.sum, err = sumCellRange(f, sheetName, config.cellRange)
+	if err != nil {
+		c.err = i18n.Errorf("合計計算エラー: %w", err)
+		return
+	}
+	c.upper = getFloatCellValue(f, sheetName, config.upperSumCell)
+	c.bottom = getFloatCellValue(f, sheetName, config.cellSum)
+	return
+}
+
+// validateExcelSums はExcelシート内の合計値が正しいか検証します。
+// fは RawCellValue で開いている必要があります。
+//
+// sheetsToValidate のすべてのシートについて、範囲の合計、上段の合計セル、下段の合計行の値を示すFindingを返します。
+// 一致したシートは参考情報(Info)とし、差がtoleranceを超えたシートと計算できなかったシートはルールの重大度とします。
+func validateExcelSums(f *excelize.File, tolerance float64) (findings []Finding) {
+	for _, sheetName := range sheetsToValidate {
+		i, err := f.GetSheetIndex(sheetName)
+		if err != nil || i < 0 {
+			slog.Warn(i18n.T("シート '%s' が見つかりません。スキップします。", sheetName), slog.String("sheet", sheetName))
+			continue
+		}
+
+		c := checkSheetSum(f, sheetName)
+		if c.err != nil {
+			findings = append(findings, Finding{
+				Sheet:   sheetName,
+				Message: i18n.T("合計金額の確認: %sシートの%v", sheetName, c.err),
+			})
+			continue
+		}
+
+		detail := i18n.T("%sシート %s の合計 %s, 上段 %s の値 %s, 下段 %s の値 %s",
+			sheetName, c.config.cellRange, formatAmount(c.sum),
+			c.config.upperSumCell, formatAmount(c.upper),
+			c.config.cellSum, formatAmount(c.bottom))
+		if c.ok(tolerance) {
+			findings = append(findings, Finding{
+				Severity: SeverityInfo,
+				Sheet:    sheetName,
+				Message:  i18n.T("合計金額の確認: %s (一致)", detail),
+			})
+			continue
+		}
+
+		// 差の大きい方の合計セルを示す
+		cell := c.config.upperSumCell
+		if math.Abs(c.sum-c.bottom) > math.Abs(c.sum-c.upper) {
+			cell = c.config.cellSum
+		}
+		findings = append(findings, Finding{
+			Sheet: sheetName,
+			Cell:  cell,
+			Message: i18n.T("合計金額の確認: %s の合計が正しく計算できていません: %s (差 上段 %s, 下段 %s)",
+				c.config.cellRange, detail,
+				formatAmount(c.upper-c.sum), formatAmount(c.bottom-c.sum)),
+		})
+	}
+	return
+}
+
+// formatAmount : 金額を指数表記にせず、必要な桁だけ表示する
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
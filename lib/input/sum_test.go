@@ -0,0 +1,59 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestValidateExcelSums(t *testing.T) {
+	path := createTestExcelFile(t, "testdata_sum", "sum.xlsx", func(f *excelize.File) {
+		// 入力Ⅱ: 一致
+		f.SetCellValue(headerSheetName, "O10", 100)
+		f.SetCellValue(headerSheetName, "O11", 200.25)
+		f.SetCellValue(headerSheetName, "O7", 300.25)
+		// 10品目用: 下段だけ 0.3 ずれている
+		f.SetCellValue(printSheetNameDefault, "AY13", 1000)
+		f.SetCellValue(printSheetNameDefault, "AY14", 2000)
+		f.SetCellValue(printSheetNameDefault, "AX7", 3000)
+		f.SetCellValue(printSheetNameDefault, "AU15", "合計")
+		f.SetCellValue(printSheetNameDefault, "AY15", 3000.3)
+	})
+	f, err := excelize.OpenFile(path, excelize.Options{RawCellValue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name      string
+		tolerance float64
+		want      []Severity
+		wantCell  string
+	}{
+		{"誤差を許容しない", formulaCacheTolerance, []Severity{SeverityInfo, severityUnset}, "AY15"},
+		{"許容誤差内なら一致", 0.5, []Severity{SeverityInfo, SeverityInfo}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := validateExcelSums(f, tt.tolerance)
+			var got []Severity
+			for _, fd := range findings {
+				got = append(got, fd.Severity)
+			}
+			// すべてのシートを確認し、一致しないシートがあっても途中で止めない
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("validateExcelSums() severities = %v, want %v: %v", got, tt.want, findings)
+			}
+			if cell := findings[1].Cell; cell != tt.wantCell {
+				t.Errorf("validateExcelSums() cell = %q, want %q", cell, tt.wantCell)
+			}
+		})
+	}
+
+	want := "合計金額の確認: 入力Ⅱシート O10:O109 の合計 300.25, 上段 O7 の値 300.25, 下段 O7 の値 300.25 (一致)"
+	if got := validateExcelSums(f, 0)[0].Message; got != want {
+		t.Errorf("validateExcelSums() message = %q, want %q", got, want)
+	}
+}
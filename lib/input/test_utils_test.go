@@ -10,7 +10,7 @@ import (
 )
 
 // createTestExcelFile はテスト用のExcelファイルを作成します。
-func createTestExcelFile(t *testing.T, dir, filename string, layoutFunc func(f *excelize.File)) string {
+func createTestExcelFile(t testing.TB, dir, filename string, layoutFunc func(f *excelize.File)) string {
 	t.Helper()
 	filePath := filepath.Join(os.TempDir(), dir, filename) // Use os.TempDir() for isolation
 	f := excelize.NewFile()
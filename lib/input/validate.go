@@ -1,23 +1,32 @@
 package input
 
 import (
+	"cmp"
 	"encoding/json"
-	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/i18n"
 )
 
 const (
 	projectIDLength  = 12
 	projectAssyDigit = 9
 	projectAssyValue = 6
-	lastRow          = 101 // 入力Iの最終行は101行固定
+
+	// 数式の保存値と再計算値を同じとみなす誤差
+	formulaCacheTolerance = 1e-6
+
+	// 並び順の誤りのまとめに表示する、移動する行の最大数
+	maxSortMoves = 10
 )
 
 // 合計値を確認するシート名
@@ -29,155 +38,216 @@ var sheetsToValidate = []string{
 	"100品目用",
 }
 
-// hiddenColumns : 隠し列一覧
-var hiddenColumns = []string{
-	"B", "C", "D", "H", "L", "P", "Q", "R", "S", "T", "U", "V", "W", "X", "Z",
-	"AA", "AB", "AC", "AD", "AE", "AF", "AG", "AH", "AI", "AK", "AL", "AM", "AN",
-	"AO", "AP", "AQ", "AR", "AS", "AT", "AU", "AV", "AW", "AX", "AY", "AZ", "BA",
-	"BB", "BC", "BD",
-}
-
-// CollectLocalErrors はローカルとAPIの一次検証エラーを収集します
-func CollectLocalErrors(sheet *Sheet, filePath string) (errs []string) {
-	// 各シートの合計値の検証
-	if err := validateExcelSums(filePath); err != nil {
-		errs = append(errs, fmt.Sprintf("合計金額の確認: %s", err))
-	}
-
-	// 隠し列に余計な文字列がないか検証
-	if err := validateHiddenColumns(filePath); err != nil {
-		errs = append(errs, fmt.Sprintf("隠し列が空である確認: %s", err))
-	}
-
-	// 要求票の版番号
-	if err := validateSheetVersion(sheet.Version); err != nil {
-		errs = append(errs, fmt.Sprintf("要求票の版番号の確認: %s", err))
-	}
-
-	// 出力日時が要求年月日より未来だったらエラー
-	if err := validateFutureRequest(sheet.RequestDate); err != nil {
-		errs = append(errs, err.Error())
-	}
-
-	// ソート順が異なるとエラー
-	if err := sortValidation(sheet); err != nil {
-		errs = append(errs, err.Error())
-	}
-
-	return
-}
-
-func sortValidation(sheet *Sheet) error {
+// sortValidation は入力Ⅰが要望納期と品番の順に並んでいるか検証し、
+// 並び順の誤りごとのFindingと、移動する行と移動先をまとめたFindingを返します。
+// まとめには移動しない行を含めず、maxSortMoves 行を超える分は件数だけ表示します。
+func sortValidation(sheet *Sheet) []Finding {
 	prjID := sheet.ProjectID
 	// 10桁目が6 == 組部品なのでソートチェックをしない
 	if len(prjID) < projectIDLength {
-		return fmt.Errorf("製番の桁数が異常です。%s", prjID)
+		return []Finding{{Message: i18n.T("製番の桁数が異常です。%s", prjID)}}
 	}
 
-	class, err := strconv.Atoi(prjID[projectAssyDigit : projectAssyDigit+1])
-	if err != nil {
-		return fmt.Errorf("製番の値が異常です。%s", prjID)
+	if _, err := strconv.Atoi(prjID[projectAssyDigit : projectAssyDigit+1]); err != nil {
+		return []Finding{{Message: i18n.T("製番の値が異常です。%s", prjID)}}
 	}
 	// 組部品はソートされてなくてOK
-	if class == projectAssyValue {
+	if isAssyProject(prjID) {
 		return nil
 	}
 
 	// ソートされていることの確認
-	if err := checkOrderItemsSortOrder(sheet.Orders); err != nil {
-		return fmt.Errorf("入力Iが納期と品番順にソートされていません: %v", err)
+	findings := checkOrderItemsSortOrder(sheet.Orders)
+	if len(findings) == 0 {
+		return nil
 	}
-
-	return nil
+	sorted := slices.Clone(sheet.Orders)
+	slices.SortStableFunc(sorted, compareOrders)
+	var moves []string
+	for i, o := range sorted {
+		// 並べ替えた後のi番目の明細は、i番目の明細があった行に移る
+		if dst := sheet.Orders[i].Row; dst != o.Row {
+			moves = append(moves, i18n.T("%d行目(%s %s) → %d行目", o.Row, o.Deadline, o.Pid, dst))
+		}
+	}
+	if len(moves) > maxSortMoves {
+		moves = append(moves[:maxSortMoves], i18n.T("ほか%d行", len(moves)-maxSortMoves))
+	}
+	return append(findings, Finding{
+		Sheet: orderSheetName,
+		Message: i18n.T("入力Iが納期と品番順にソートされていません (%d箇所)。移動する行: %s",
+			len(findings), strings.Join(moves, ", ")),
+	})
 }
 
 // checkOrderItemsSortOrder : 注文明細の並び順チェック
-func checkOrderItemsSortOrder(orders Orders) error {
-	n := len(orders)
+//
+// 隣り合う明細を要望納期の昇順、同じ要望納期なら品番の自然順で比較し、
+// 前の行より先に来るべき行ごとにFindingを返す。
+func checkOrderItemsSortOrder(orders Orders) (findings []Finding) {
+	for i := 1; i < len(orders); i++ {
+		prev, cur := orders[i-1], orders[i]
+		if compareOrders(prev, cur) <= 0 {
+			continue
+		}
+		f := Finding{Sheet: orderSheetName, Row: cur.Row}
+		if compareDeadlines(prev.Deadline, cur.Deadline) != 0 {
+			f.Cell = colDeadlineO + strconv.Itoa(cur.Row)
+			f.Message = i18n.T("入力I %d行目: 要望納期 '%s' が前の%d行目の '%s' より前です",
+				cur.Row, cur.Deadline, prev.Row, prev.Deadline)
+		} else {
+			f.Cell = colPid + strconv.Itoa(cur.Row)
+			f.Message = i18n.T("入力I %d行目: 品番 '%s' が同じ要望納期 '%s' の前の%d行目の品番 '%s' より前です",
+				cur.Row, cur.Pid, cur.Deadline, prev.Row, prev.Pid)
+		}
+		findings = append(findings, f)
+	}
+	return
+}
 
-	// 要素数が0または1の場合は常に正しい並び順とみなす
-	if n <= 1 {
-		return nil
+// compareOrders : 要望納期の昇順、同じ要望納期なら品番の自然順で比較する
+func compareOrders(a, b Order) int {
+	if c := compareDeadlines(a.Deadline, b.Deadline); c != 0 {
+		return c
 	}
+	return naturalCompare(a.Pid, b.Pid)
+}
 
-	// 各要素と次の要素のペアを比較していく
-	for i := 0; i < n-1; i++ {
-		current := orders[i]
-		next := orders[i+1]
-
-		// 比較ルール: 要望納期昇順 -> 品番昇順
-		// current が next より後に来ていたら不正
-
-		// 1. 要望納期を比較 (string型での比較)
-		// current.Deadline > next.Deadline の場合は不正
-		if current.Deadline > next.Deadline {
-			return fmt.Errorf(
-				"インデックス %d と %d で並べ替え順から外れた項目を注文しています：要望納期 '%s' は '%s' の後です。",
-				i, i+1, current.Deadline, next.Deadline,
-			)
-		}
+// compareDeadlines : 日付として解釈できれば日付で、できなければ文字列で比較する
+func compareDeadlines(a, b string) int {
+	da, errA := time.Parse(DateLayout, a)
+	db, errB := time.Parse(DateLayout, b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return da.Compare(db)
+}
 
-		// 2. 要望納期が同じ場合、品番を比較 (string型での比較)
-		// current.Deadline == next.Deadline かつ current.Pid > next.Pid の場合は不正
-		if current.Deadline == next.Deadline && current.Pid > next.Pid {
-			return fmt.Errorf("インデックス %d と %d で並べ替え順から外れた項目を注文しています：品番 '%s' は同じ要望納期 '%s' の '%s' の後にあります。",
-				i, i+1, current.Pid, next.Pid, current.Deadline)
+// naturalCompare は文字列中の数字の並びを数値として比較します。
+// "PN-9" は "PN-10" より前になります。数値が等しければ文字列として比較します。
+func naturalCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !isASCIIDigit(a[i]) || !isASCIIDigit(b[j]) {
+			if a[i] != b[j] {
+				return cmp.Compare(a[i], b[j])
+			}
+			i++
+			j++
+			continue
 		}
-		// current.Pid <= next.Pid の場合は正しい順序、または同じ要素なのでOK
-
-		// current.Deadline < next.Deadline の場合、または current.Deadline == next.Deadline && current.Pid <= next.Pid の場合は正しい順序、次のペアへ進む
+		// 数字の並びを切り出し、先頭の0を除いて桁数、値の順に比較する
+		si, sj := i, j
+		for i < len(a) && isASCIIDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isASCIIDigit(b[j]) {
+			j++
+		}
+		na := strings.TrimLeft(a[si:i], "0")
+		nb := strings.TrimLeft(b[sj:j], "0")
+		if c := cmp.Compare(len(na), len(nb)); c != 0 {
+			return c
+		}
+		if c := strings.Compare(na, nb); c != 0 {
+			return c
+		}
+	}
+	if c := cmp.Compare(len(a)-i, len(b)-j); c != 0 {
+		return c
 	}
+	return strings.Compare(a, b)
+}
 
-	// 全てのペアの比較が完了し、不正な並び順が見つからなかった
-	return nil
+// isASCIIDigit : 半角数字ならtrue
+func isASCIIDigit(c byte) bool {
+	return '0' <= c && c <= '9'
 }
 
-// validateExcelSums はExcelシート内の合計値が正しいか検証します。
-func validateExcelSums(filePath string) error {
+// validateFormulaCache は合計セルと行ごとの金額セルについて、
+// Excelに保存されている計算結果とexcelizeで再計算した値を比較します。
+//
+// 再計算せずに保存するツールで作成・編集されたファイルでは保存値が古く、
+// validateExcelSums の結果が信用できないため、値が異なるセルを警告として返します。
+func validateFormulaCache(f *excelize.File) (warns []string) {
 	opts := excelize.Options{RawCellValue: true}
-	f, err := excelize.OpenFile(filePath, opts)
-	if err != nil {
-		slog.Warn("ファイルを開けません。合計値チェックをスキップします。",
-			slog.String("filePath", filePath),
-			slog.String("error", err.Error()),
-		)
-		return nil
-	}
-	defer f.Close()
-
 	for _, sheetName := range sheetsToValidate {
-		i, err := f.GetSheetIndex(sheetName)
-		if err != nil || i < 0 {
-			slog.Warn(fmt.Sprintf("シート '%s' が見つかりません。スキップします。", sheetName), slog.String("sheet", sheetName))
+		if i, err := f.GetSheetIndex(sheetName); err != nil || i < 0 {
 			continue
 		}
-
-		// レンジの合計値算出
 		config, err := getSheetValidationConfig(f, sheetName)
 		if err != nil {
-			return fmt.Errorf("%sシートの合計計算設定エラー: %w", sheetName, err)
+			continue // 合計計算設定のエラーは validateExcelSums が報告する
 		}
-		sum, err := sumCellRange(f, sheetName, config.cellRange)
+		cells, err := cellsInRange(config.cellRange)
 		if err != nil {
-			return fmt.Errorf("%sシートの合計計算エラー: %w", sheetName, err)
+			continue
 		}
-
-		// レンジの合計と上下それぞれの合計値が等しくなければエラーを返す
-		err = fmt.Errorf(
-			"%sシートにおいて、%s の合計が正しく計算できていません",
-			sheetName, config.cellRange,
-		)
-		valUpperSumCell := getFloatCellValue(f, sheetName, config.upperSumCell)
-		if sum != valUpperSumCell {
-			return err
+		// 入力Ⅱは上下の合計セルがどちらもO7
+		cells = append(cells, config.upperSumCell)
+		if config.cellSum != config.upperSumCell {
+			cells = append(cells, config.cellSum)
 		}
-		valCellSum := getFloatCellValue(f, sheetName, config.cellSum)
-		if sum != valCellSum {
-			return err
+
+		for _, cell := range cells {
+			if w := checkFormulaCache(f, sheetName, cell, opts); w != "" {
+				warns = append(warns, w)
+			}
 		}
 	}
+	return
+}
 
-	return nil
+// checkFormulaCache : 数式セルの保存値と再計算値が異なれば警告文を返す
+func checkFormulaCache(f *excelize.File, sheetName, cell string, opts excelize.Options) string {
+	formula, err := f.GetCellFormula(sheetName, cell)
+	if err != nil || formula == "" {
+		return "" // 数式でなければ再計算の必要なし
+	}
+	calc, err := f.CalcCellValue(sheetName, cell, opts)
+	if err != nil {
+		slog.Warn(i18n.T("数式を再計算できません。"),
+			slog.String("sheet", sheetName),
+			slog.String("cell", cell),
+			slog.String("formula", formula),
+			slog.String("error", err.Error()),
+		)
+		return ""
+	}
+	cached := getFloatCellValue(f, sheetName, cell)
+	recalculated, err := parseFloatSafe(calc)
+	if err != nil {
+		return "" // 数値以外の計算結果は比較しない
+	}
+	if math.Abs(cached-recalculated) < formulaCacheTolerance {
+		return ""
+	}
+	return i18n.T(
+		"数式の保存値が再計算値と一致しません: %s!%s (=%s) 保存値 %g, 再計算値 %g。"+
+			"Excelで開いて再計算してから保存してください",
+		sheetName, cell, formula, cached, recalculated,
+	)
+}
+
+// cellsInRange : "AY13:AY20" のような単一列の範囲のセル番地を返す
+func cellsInRange(cellRange string) ([]string, error) {
+	parts := strings.Split(cellRange, ":")
+	if len(parts) != 2 {
+		return nil, i18n.Errorf("無効なセル範囲形式: %s", cellRange)
+	}
+	col, startRow, err := excelize.SplitCellName(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	_, endRow, err := excelize.SplitCellName(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	cells := make([]string, 0, endRow-startRow+1)
+	for r := startRow; r <= endRow; r++ {
+		cells = append(cells, col+strconv.Itoa(r))
+	}
+	return cells, nil
 }
 
 // validateSheetVersion : 要求票の版番号確認を行う
@@ -193,12 +263,12 @@ func validateExcelSums(filePath string) error {
 func validateSheetVersion(localVersion string) error {
 	// バージョンが空文字列の場合の警告（サーバー側またはローカル側）
 	if localVersion == "" {
-		slog.Warn("ローカルシートのバージョンが空です。サーバーと比較できません。")
+		slog.Warn(i18n.T("ローカルシートのバージョンが空です。サーバーと比較できません。"))
 	}
 
 	// サーバーテンプレートのバージョンを取得
 	if ServerAddress == "" {
-		slog.Warn("APIサーバーアドレスが未設定のため、バージョンチェックをスキップします。",
+		slog.Warn(i18n.T("APIサーバーアドレスが未設定のため、バージョンチェックをスキップします。"),
 			slog.String("hint", `go build -ldflags="-X pncheck/lib/input.ServerAddress=http://localhost:8080"`),
 		)
 		return nil
@@ -209,18 +279,18 @@ func validateSheetVersion(localVersion string) error {
 
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
-		return fmt.Errorf("HTTPリクエストの作成に失敗しました (%s): %w", apiURL, err)
+		return i18n.Errorf("HTTPリクエストの作成に失敗しました (%s): %w", apiURL, err)
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("APIへのリクエスト送信に失敗しました (%s): %w", apiURL, err)
+		return i18n.Errorf("APIへのリクエスト送信に失敗しました (%s): %w", apiURL, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body) // エラーボディも読み込んでログに含める
-		return fmt.Errorf(
+		return i18n.Errorf(
 			"サーバーからのバージョン取得に失敗しました。ステータスコード: %d, レスポンス: %s",
 			resp.StatusCode,
 			string(bodyBytes),
@@ -229,27 +299,27 @@ func validateSheetVersion(localVersion string) error {
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("APIレスポンスボディの読み込みに失敗しました: %w", err)
+		return i18n.Errorf("APIレスポンスボディの読み込みに失敗しました: %w", err)
 	}
 
 	var serverResp ServerVersionResponse
 	if err := json.Unmarshal(body, &serverResp); err != nil {
-		return fmt.Errorf("サーバー応答のJSON解析に失敗しました: %w, レスポンス: %s",
+		return i18n.Errorf("サーバー応答のJSON解析に失敗しました: %w, レスポンス: %s",
 			err, string(body))
 	}
 
 	serverSheetVersion := serverResp.SheetVersion
 
 	if serverSheetVersion == "" {
-		slog.Warn("サーバーからのシートバージョンが空です。比較に失敗しました。", slog.
+		slog.Warn(i18n.T("サーバーからのシートバージョンが空です。比較に失敗しました。"), slog.
 			String("apiURL", apiURL))
 		// サーバーのバージョンが空の場合、有効なバージョンではないとみなしエラーを返す
-		return fmt.Errorf("サーバーから有効なシートバージョンが取得できませんでした。")
+		return i18n.Errorf("サーバーから有効なシートバージョンが取得できませんでした。")
 	}
 
 	// バージョンの比較
 	if localVersion != serverSheetVersion {
-		return fmt.Errorf(
+		return i18n.Errorf(
 			"要求票のバージョンが一致しません。"+
 				"ローカル: '%s', サーバー: %s' です。"+
 				"最新の要求票テンプレートをご利用ください。",
@@ -264,44 +334,11 @@ func validateFutureRequest(reqDate string) error {
 	now := time.Now()
 	req, err := time.Parse(DateLayout, reqDate)
 	if err != nil {
-		return fmt.Errorf("時間型の解釈に失敗しました: %w", err)
+		return i18n.Errorf("時間型の解釈に失敗しました: %w", err)
 	}
 	if req.After(now) {
-		return fmt.Errorf("要求年月日 %s が未来の日付です", reqDate)
+		return i18n.Errorf("要求年月日 %s が未来の日付です", reqDate)
 	}
 	return nil
 
 }
-
-// IsEmptyColumn : 指定列のordersStartRowからlastRow までがすべて空文字かどうかを返す
-func IsEmptyColumn(f *excelize.File, sheetName, col string) bool {
-	for r := ordersStartRow; r <= lastRow; r++ {
-		if getCellValue(f, sheetName, col+strconv.Itoa(r)) != "" {
-			return false
-		}
-	}
-	return true
-}
-
-// validateHiddenColums : 入力Iの隠し列に入力がないか検証する
-func validateHiddenColumns(filePath string) error {
-	f, err := excelize.OpenFile(filePath, excelize.Options{RawCellValue: true})
-
-	if err != nil {
-		slog.Warn("ファイルを開けません。隠し列チェックをスキップします。", slog.String("filePath", filePath))
-		return nil
-	}
-	defer f.Close()
-
-	var dirty []string
-	for _, col := range hiddenColumns {
-		if !IsEmptyColumn(f, orderSheetName, col) {
-			dirty = append(dirty, col)
-		}
-	}
-
-	if len(dirty) > 0 {
-		return fmt.Errorf("隠し列に入力があります: %s", strings.Join(dirty, ", "))
-	}
-	return nil
-}
@@ -2,16 +2,17 @@ package input
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/xuri/excelize/v2"
+
+	"pncheck/lib/config"
 )
 
-func Test_CollectLocalErrors(t *testing.T) {
+func TestEngine_Run(t *testing.T) {
 	// モック用の現在時刻
 	now := time.Now()
 	yesterday := now.AddDate(0, 0, -1).Format(DateLayout)
@@ -94,11 +95,24 @@ func Test_CollectLocalErrors(t *testing.T) {
 			// エラーを返すケースも個別にテストする必要があります。
 			// ここではロジックの分岐を確認します。
 
-			gotErrs := CollectLocalErrors(tt.sheet, tt.filePath)
+			// ファイルの内容に依存する検証は空のワークブックでスキップさせる
+			wb := &Workbook{File: excelize.NewFile(), Path: tt.filePath}
+			defer wb.Close()
+			engine, err := NewEngine(config.Default())
+			if err != nil {
+				t.Fatalf("NewEngine() error = %v", err)
+			}
+			// ファイル名の警告などは対象外とし、Fatalのみ比較する
+			var gotErrs []string
+			for _, f := range engine.Run(wb, tt.sheet) {
+				if f.Severity == SeverityFatal {
+					gotErrs = append(gotErrs, f.Message)
+				}
+			}
 
 			// エラーメッセージの比較（完全一致だと難しい場合があるため、含まれているかで判定することもあります）
 			if len(gotErrs) != len(tt.wantErrs) {
-				t.Errorf("CollectLocalErrors() = %v, want %v", gotErrs, tt.wantErrs)
+				t.Errorf("Engine.Run() = %v, want %v", gotErrs, tt.wantErrs)
 				return
 			}
 			for i := range gotErrs {
@@ -118,75 +132,86 @@ func Test_CollectLocalErrors(t *testing.T) {
 func TestCheckOrderItemsSortOrder(t *testing.T) {
 	// テストケースを定義
 	tests := []struct {
-		name        string // テストケースの名前
-		sheet       Sheet  // 入力となるSheetデータ
-		expectError bool   // エラーが期待されるか (true: 期待する, false: 期待しない)
-		// expectedErrorMsg string // エラーメッセージの詳細をチェックする場合に使う (今回はシンプルにエラーの有無のみ)
+		name     string // テストケースの名前
+		sheet    Sheet  // 入力となるSheetデータ
+		wantRows []int  // 並び順の誤りとして示される行番号
 	}{
 		{
-			name:        "Empty Orders - Should Pass",
-			sheet:       Sheet{Orders: Orders{}},
-			expectError: false,
+			name:  "Empty Orders - Should Pass",
+			sheet: Sheet{Orders: Orders{}},
 		},
 		{
-			name:        "Single Order - Should Pass",
-			sheet:       Sheet{Orders: Orders{{Deadline: "2023-10-26", Pid: "A001"}}},
-			expectError: false,
+			name:  "Single Order - Should Pass",
+			sheet: Sheet{Orders: Orders{{Deadline: "2023/10/26", Pid: "A001", Row: 2}}},
 		},
 		{
 			name: "Correct Sort by Deadline Only - Should Pass",
 			sheet: Sheet{Orders: Orders{
-				{Deadline: "2023-10-26", Pid: "C003"}, // Pidは順不同でもOK (Deadlineが違うから)
-				{Deadline: "2023-10-27", Pid: "A001"},
-				{Deadline: "2023-10-28", Pid: "B002"},
+				{Deadline: "2023/10/26", Pid: "C003", Row: 2}, // Pidは順不同でもOK (Deadlineが違うから)
+				{Deadline: "2023/10/27", Pid: "A001", Row: 3},
+				{Deadline: "2023/10/28", Pid: "B002", Row: 4},
 			}},
-			expectError: false,
 		},
 		{
 			name: "Correct Sort by Deadline and Pid - Should Pass",
 			sheet: Sheet{Orders: Orders{
-				{Deadline: "2023-10-26", Pid: "A001"},
-				{Deadline: "2023-10-26", Pid: "B002"},
-				{Deadline: "2023-10-27", Pid: "C003"},
-				{Deadline: "2023-10-27", Pid: "D004"},
-				{Deadline: "2023-10-28", Pid: "A001"},
+				{Deadline: "2023/10/26", Pid: "A001", Row: 2},
+				{Deadline: "2023/10/26", Pid: "B002", Row: 3},
+				{Deadline: "2023/10/27", Pid: "C003", Row: 4},
+				{Deadline: "2023/10/27", Pid: "D004", Row: 5},
+				{Deadline: "2023/10/28", Pid: "A001", Row: 6},
 			}},
-			expectError: false,
 		},
 		{
 			name: "Incorrect Sort - Deadline Out of Order - Should Fail",
 			sheet: Sheet{Orders: Orders{
-				{Deadline: "2023-10-27", Pid: "C003"},
-				{Deadline: "2023-10-26", Pid: "A001"}, // 2023-10-27 の後に 2023-10-26
+				{Deadline: "2023/10/27", Pid: "C003", Row: 2},
+				{Deadline: "2023/10/26", Pid: "A001", Row: 3}, // 2023/10/27 の後に 2023/10/26
 			}},
-			expectError: true,
+			wantRows: []int{3},
 		},
 		{
 			name: "Incorrect Sort - Pid Out of Order for Same Deadline - Should Fail",
 			sheet: Sheet{Orders: Orders{
-				{Deadline: "2023-10-26", Pid: "B002"},
-				{Deadline: "2023-10-26", Pid: "A001"}, // 同じ納期で B002 の後に A001
+				{Deadline: "2023/10/26", Pid: "B002", Row: 2},
+				{Deadline: "2023/10/26", Pid: "A001", Row: 3}, // 同じ納期で B002 の後に A001
 			}},
-			expectError: true,
+			wantRows: []int{3},
 		},
 		{
-			name: "Incorrect Sort - Pid Out of Order for Same Deadline (Multiple Items) - Should Fail",
+			name: "Incorrect Sort - All Violations Are Reported - Should Fail",
 			sheet: Sheet{Orders: Orders{
-				{Deadline: "2023-10-26", Pid: "A001"}, // OK
-				{Deadline: "2023-10-27", Pid: "D004"}, // OK (Deadlineが違う)
-				{Deadline: "2023-10-27", Pid: "C003"}, // Fail (同じ納期で D004の後に C003)
-				{Deadline: "2023-10-28", Pid: "A001"}, // この行には到達しないはず
+				{Deadline: "2023/10/26", Pid: "A001", Row: 2}, // OK
+				{Deadline: "2023/10/27", Pid: "D004", Row: 3}, // OK (Deadlineが違う)
+				{Deadline: "2023/10/27", Pid: "C003", Row: 4}, // Fail (同じ納期で D004の後に C003)
+				{Deadline: "2023/10/28", Pid: "A001", Row: 5}, // OK
+				{Deadline: "2023/10/01", Pid: "A001", Row: 6}, // Fail (最初の誤りの後も確認する)
 			}},
-			expectError: true,
+			wantRows: []int{4, 6},
 		},
 		{
 			name: "Correct Sort with Duplicate Items - Should Pass",
 			sheet: Sheet{Orders: Orders{
-				{Deadline: "2023-10-26", Pid: "A001"},
-				{Deadline: "2023-10-26", Pid: "A001"}, // 同じアイテムでも順序はOK
-				{Deadline: "2023-10-27", Pid: "B002"},
+				{Deadline: "2023/10/26", Pid: "A001", Row: 2},
+				{Deadline: "2023/10/26", Pid: "A001", Row: 3}, // 同じアイテムでも順序はOK
+				{Deadline: "2023/10/27", Pid: "B002", Row: 4},
+			}},
+		},
+		{
+			name: "Natural Pid Order - Should Pass",
+			sheet: Sheet{Orders: Orders{
+				{Deadline: "2023/10/26", Pid: "PN-9", Row: 2},
+				{Deadline: "2023/10/26", Pid: "PN-10", Row: 3}, // 文字列では PN-10 < PN-9 だが数値として比較する
+				{Deadline: "2023/10/26", Pid: "PN-10-2", Row: 4},
 			}},
-			expectError: false,
+		},
+		{
+			name: "Natural Pid Order - Should Fail",
+			sheet: Sheet{Orders: Orders{
+				{Deadline: "2023/10/26", Pid: "PN-10", Row: 2},
+				{Deadline: "2023/10/26", Pid: "PN-9", Row: 3},
+			}},
+			wantRows: []int{3},
 		},
 	}
 
@@ -194,215 +219,89 @@ func TestCheckOrderItemsSortOrder(t *testing.T) {
 	for _, tt := range tests {
 		// t.Run を使うと、各テストケースが独立して実行され、結果が見やすくなります
 		t.Run(tt.name, func(t *testing.T) {
-			err := checkOrderItemsSortOrder(tt.sheet.Orders)
-
-			// エラーが期待されているかチェック
-			if tt.expectError {
-				// エラーが期待されているのに nil が返ってきた場合
-				if err == nil {
-					t.Errorf("Expected an error, but got nil")
-				}
-				// (オプション) 特定のエラーメッセージをチェックする場合
-				// if err != nil && tt.expectedErrorMsg != "" && err.Error() !=tt.expectedErrorMsg {
-				//      t.Errorf("Expected error message '%s', but got '%s'", tt.expectedErrorMsg, err.Error())
-				// }
-			} else {
-				// エラーが期待されていないのに nil 以外が返ってきた場合
-				if err != nil {
-					t.Errorf("Did not expect an error, but got: %v", err)
-				}
+			var gotRows []int
+			for _, f := range checkOrderItemsSortOrder(tt.sheet.Orders) {
+				gotRows = append(gotRows, f.Row)
+			}
+			if !reflect.DeepEqual(gotRows, tt.wantRows) {
+				t.Errorf("checkOrderItemsSortOrder() rows = %v, want %v", gotRows, tt.wantRows)
 			}
 		})
 	}
 }
 
-func TestIsEmptyColumn(t *testing.T) {
-	t.Parallel()
-
-	// ヘルパー: テスト用のインメモリExcelファイルを生成
-	setupFile := func(t *testing.T, data map[string]string) *excelize.File {
-		t.Helper()
-		f := excelize.NewFile()
-		_, err := f.NewSheet(orderSheetName)
-		if err != nil {
-			t.Fatalf("Failed to create sheet: %v", err)
-		}
-		for axis, val := range data {
-			if err := f.SetCellValue(orderSheetName, axis, val); err != nil {
-				t.Fatalf("Failed to set cell value: %v", err)
-			}
-		}
-		return f
-	}
-
-	tests := []struct {
-		name      string
-		excelData map[string]string
-		col       string
-		lastRow   int
-		want      bool
-	}{
-		{
-			name:      "指定範囲がすべて空文字の場合_trueを返す",
-			excelData: map[string]string{}, // データなし
-			col:       "A",
-			want:      true,
-		},
-		{
-			name: "指定範囲外(開始行未満)に値があっても影響せず_trueを返す",
-			excelData: map[string]string{
-				"A1": "header", // ordersStartRow(2) 未満の1行目
-			},
-			col:  "A",
-			want: true,
-		},
-		{
-			name: "指定範囲外(最終行超)に値があっても影響せず_trueを返す",
-			excelData: map[string]string{
-				"A102": "value", // lastRow(101) を超えた102行目
-			},
-			col:  "A",
-			want: true,
-		},
-		{
-			name: "指定範囲の途中に値がある場合_falseを返す",
-			excelData: map[string]string{
-				"A3": "dirty", // ordersStartRow(2) と lastRow(101) の間
-			},
-			col:  "A",
-			want: false,
-		},
-		{
-			name: "指定範囲の境界(開始行)に値がある場合_falseを返す",
-			excelData: map[string]string{
-				"A2": "start_edge",
-			},
-			col:  "A",
-			want: false,
-		},
-		{
-			name: "指定範囲の境界(最終行)に値がある場合_falseを返す",
-			excelData: map[string]string{
-				"A101": "end_edge",
-			},
-			col:  "A",
-			want: false,
+func TestSortValidation_ExpectedOrder(t *testing.T) {
+	sheet := &Sheet{
+		Header: Header{ProjectID: "123456789000"},
+		Orders: Orders{
+			{Deadline: "2023/10/27", Pid: "A001", Row: 2},
+			{Deadline: "2023/10/26", Pid: "PN-10", Row: 3},
+			{Deadline: "2023/10/26", Pid: "PN-9", Row: 4},
 		},
 	}
+	findings := sortValidation(sheet)
+	if len(findings) != 3 {
+		t.Fatalf("sortValidation() = %v, want 3 findings", findings)
+	}
+	if got, want := findings[0].Message, "入力I 3行目: 要望納期 '2023/10/26' が前の2行目の '2023/10/27' より前です"; got != want {
+		t.Errorf("sortValidation() message = %q, want %q", got, want)
+	}
+	// 移動しない3行目は表示しない
+	want := "入力Iが納期と品番順にソートされていません (2箇所)。移動する行: " +
+		"4行目(2023/10/26 PN-9) → 2行目, 2行目(2023/10/27 A001) → 4行目"
+	if got := findings[2].Message; got != want {
+		t.Errorf("sortValidation() summary = %q, want %q", got, want)
+	}
 
-	for _, tt := range tests {
-		tt := tt // Go 1.22未満のループ変数の参照対策
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
-			f := setupFile(t, tt.excelData)
-			defer f.Close()
-
-			got := IsEmptyColumn(f, orderSheetName, tt.col)
-			if got != tt.want {
-				t.Errorf("IsEmptyColumn() = %v, want %v", got, tt.want)
-			}
-		})
+	// 移動する行が多ければ maxSortMoves 行までに抑える
+	sheet.Orders = nil
+	for i := range 30 {
+		sheet.Orders = append(sheet.Orders, Order{Deadline: "2023/10/26", Pid: fmt.Sprintf("PN-%d", 30-i), Row: i + 2})
+	}
+	findings = sortValidation(sheet)
+	summary := findings[len(findings)-1].Message
+	if n := strings.Count(summary, "→"); n != maxSortMoves {
+		t.Errorf("sortValidation() summary の移動は%d行, want %d: %s", n, maxSortMoves, summary)
+	}
+	if !strings.HasSuffix(summary, ", ほか20行") {
+		t.Errorf("sortValidation() summary = %q, want suffix ほか20行", summary)
 	}
 }
 
-func TestValidateHiddenColumns(t *testing.T) {
-	t.Parallel()
-
-	// ヘルパー: テスト用の一時Excelファイルを作成してパスを返す
-	createTempExcel := func(t *testing.T, data map[string]string) string {
-		t.Helper()
-		f := excelize.NewFile()
-		_, err := f.NewSheet(orderSheetName)
-		if err != nil {
-			t.Fatalf("Failed to create sheet: %v", err)
+func TestValidateFormulaCache(t *testing.T) {
+	// 入力ⅡのO7に合計の数式を設定し、保存値を cached とする
+	layout := func(cached int) func(f *excelize.File) {
+		return func(f *excelize.File) {
+			f.SetCellValue(headerSheetName, "O10", 100)
+			f.SetCellValue(headerSheetName, "O11", 200)
+			f.SetCellValue(headerSheetName, "O7", cached)
+			f.SetCellFormula(headerSheetName, "O7", "SUM(O10:O109)")
 		}
-
-		// テストデータの書き込み
-		for axis, val := range data {
-			if err := f.SetCellValue(orderSheetName, axis, val); err != nil {
-				t.Fatalf("Failed to set cell value: %v", err)
-			}
-		}
-
-		// 一時ディレクトリに保存
-		tmpDir := t.TempDir()
-		filePath := filepath.Join(tmpDir, "test_order.xlsx")
-		if err := f.SaveAs(filePath); err != nil {
-			t.Fatalf("Failed to save temp file: %v", err)
-		}
-		return filePath
 	}
 
 	tests := []struct {
 		name      string
-		setupFile func(t *testing.T) string
-		wantErr   bool
-		errMsg    string // エラーメッセージに含まれるべきキーワード
+		cached    int
+		wantWarns int
 	}{
-		{
-			name: "隠し列(B, D)に一切入力がない場合_正常終了(nil)",
-			setupFile: func(t *testing.T) string {
-				return createTempExcel(t, map[string]string{
-					"A1": "HeaderA", "B1": "HiddenHeaderB", "C1": "HeaderC", "D1": "HiddenHeaderD",
-					"A2": "data", "E2": "data", // 隠し列(B, D)のデータ行(2行目以降)は空
-				})
-			},
-			wantErr: false,
-		},
-		{
-			name: "隠し列Bに入力がある場合_エラーを返す",
-			setupFile: func(t *testing.T) string {
-				return createTempExcel(t, map[string]string{
-					"A2": "data",
-					"B2": "invalid_data", // 隠し列Bに入力あり
-				})
-			},
-			wantErr: true,
-			errMsg:  "隠し列に入力があります: B",
-		},
-		{
-			name: "複数の隠し列(B, D)に入力がある場合_カンマ区切りでエラーを返す",
-			setupFile: func(t *testing.T) string {
-				return createTempExcel(t, map[string]string{
-					"A2": "data",
-					"B2": "invalid_data1", // 隠し列Bに入力あり
-					"C2": "invalid_data2", // 隠し列Bに入力あり
-					"D3": "invalid_data3", // 隠し列Dに入力あり
-				})
-			},
-			wantErr: true,
-			errMsg:  "隠し列に入力があります: B, C, D",
-		},
-		{
-			name: "ファイルが存在しない場合_ワーニングを出力して正常終了(nil)を返す(仕様)",
-			setupFile: func(t *testing.T) string {
-				return "non_existent_file.xlsx"
-			},
-			wantErr: false,
-		},
+		{"保存値が再計算値と一致", 300, 0},
+		{"保存値が古い", 100, 1},
 	}
-
 	for _, tt := range tests {
-		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
-			filePath := tt.setupFile(t)
-
-			// 存在するファイルの場合は後片付け（t.TempDir() を使っているため自動削除されるが明示的にも削除可能）
-			if _, err := os.Stat(filePath); err == nil {
-				defer os.Remove(filePath)
+			filePath := createTestExcelFile(t, "testdata_formula", "formula.xlsx", layout(tt.cached))
+			wb, err := OpenWorkbook(filePath)
+			if err != nil {
+				t.Fatalf("OpenWorkbook() error = %v", err)
 			}
+			defer wb.Close()
 
-			err := validateHiddenColumns(filePath)
-
-			if (err != nil) != tt.wantErr {
-				t.Fatalf("validateHiddenColumns() error = %v, wantErr %v", err, tt.wantErr)
+			warns := validateFormulaCache(wb.File)
+			if len(warns) != tt.wantWarns {
+				t.Fatalf("validateFormulaCache() = %v, want %d warnings", warns, tt.wantWarns)
 			}
-
-			if tt.wantErr && err != nil {
-				if !strings.Contains(err.Error(), tt.errMsg) {
-					t.Errorf("expected error message to contain %q, got %q", tt.errMsg, err.Error())
-				}
+			if tt.wantWarns > 0 && !strings.Contains(warns[0], "入力Ⅱ!O7") {
+				t.Errorf("警告にセル番地が含まれていません: %s", warns[0])
 			}
 		})
 	}
@@ -0,0 +1,179 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"pncheck/lib/i18n"
+)
+
+// 指摘の出所
+const (
+	SourceLocal    = "local"    // pncheckのローカル検証ルール
+	SourceServer   = "server"   // PNSearchの応答 (1回目のPOST)
+	SourceOverride = "override" // PNSearchが品名、型式、単位を修正したときの応答 (2回目のPOST)
+	SourceSystem   = "system"   // Excelの読み込みやAPI通信などのエラー
+)
+
+// 指摘の重大度 (input.Severity の名前と同じ)
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+	SeverityFatal   = "fatal"
+)
+
+// Finding : レポートに表示する1件の指摘
+//
+// output は input を参照できないため、input.Finding や api.ErrorRecord は
+// lib パッケージでこの型に変換して格納する。表示する文字列は出力時に String で組み立てる。
+type Finding struct {
+	Source   string `json:"source"`             // local, server, system のいずれか
+	ID       string `json:"id,omitempty"`       // ローカルはルールID、PNSearchはメッセージ
+	Severity string `json:"severity"`           // info, warning, error, fatal のいずれか
+	Sheet    string `json:"sheet,omitempty"`    // 問題のあるシート名
+	Row      int    `json:"row,omitempty"`      // 問題のある行番号
+	Index    int    `json:"index,omitempty"`    // PNSearchの明細の番号 (1始まり)。行番号が分からない場合のみ
+	Column   string `json:"column,omitempty"`   // 問題のある列 (例: "E")
+	Cell     string `json:"cell,omitempty"`     // 問題のあるセル番地 (例: "E2")
+	Field    string `json:"field,omitempty"`    // 問題のある項目名 (PNSearchのキー)
+	Message  string `json:"message"`            // 説明
+	Details  string `json:"details,omitempty"`  // PNSearchが返した詳細
+	Hint     string `json:"hint,omitempty"`     // 対処方法
+	HelpURL  string `json:"help_url,omitempty"` // 対処方法の詳しい説明のURL
+}
+
+// String : レポート表示用の文字列
+//
+// ローカル検証は設定ファイルで重大度を変更できるよう、ルールIDを添える。
+// PNSearchの指摘は詳細と行番号、項目名を添え、自動修正の応答であればそれを示す。
+func (f Finding) String() string {
+	switch f.Source {
+	case SourceLocal:
+		if f.ID != "" {
+			return fmt.Sprintf("[%s] %s", f.ID, f.Message)
+		}
+		return f.Message
+	case SourceServer:
+		return f.serverString()
+	case SourceOverride:
+		return i18n.T("PNSearchの自動修正: %s", f.serverString())
+	}
+	return f.Message
+}
+
+// serverString : PNSearchの指摘の表示用の文字列
+func (f Finding) serverString() string {
+
+	var parts []string
+	if f.Details != "" {
+		parts = append(parts, f.Details)
+	}
+	var locationParts []string
+	switch {
+	case f.Row > 0:
+		locationParts = append(locationParts, i18n.T("%d行目", f.Row))
+	case f.Index > 0:
+		locationParts = append(locationParts, i18n.T("明細%d", f.Index))
+	}
+	if f.Field != "" {
+		locationParts = append(locationParts, f.Field)
+	}
+	if len(locationParts) > 0 {
+		parts = append(parts, fmt.Sprintf("[%s]", strings.Join(locationParts, ":")))
+	}
+	if len(parts) > 0 {
+		return fmt.Sprintf("%s: %s", f.Message, strings.Join(parts, " "))
+	}
+	return f.Message
+}
+
+// Severity : ステータスコードに対応する重大度
+func (c StatusCode) Severity() string {
+	switch {
+	case c >= fatalCode:
+		return SeverityFatal
+	case c >= errorCode:
+		return SeverityError
+	case c >= warningCode:
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
+// severityCodes : 重大度に対応するステータスコード
+var severityCodes = map[string]StatusCode{
+	SeverityInfo:    successCode,
+	SeverityWarning: warningCode,
+	SeverityError:   errorCode,
+	SeverityFatal:   fatalCode,
+}
+
+// WorstStatus : 最も重い指摘の重大度に対応するステータスコード。指摘がなければ200
+func (r Report) WorstStatus() StatusCode {
+	worst := successCode
+	for _, f := range r.Findings {
+		worst = max(worst, severityCodes[f.Severity])
+	}
+	return worst
+}
+
+// Problems : 参考情報を除いた指摘
+func (r Report) Problems() (findings []Finding) {
+	for _, f := range r.Findings {
+		if f.Severity != SeverityInfo {
+			findings = append(findings, f)
+		}
+	}
+	return
+}
+
+// Notes : 合計金額の確認結果などの参考情報
+func (r Report) Notes() (findings []Finding) {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityInfo {
+			findings = append(findings, f)
+		}
+	}
+	return
+}
+
+// csvHeader : WriteCSV の見出し行
+var csvHeader = []string{
+	"file", "status", "source", "id", "severity", "sheet", "row", "index", "column", "cell", "field", "message", "details", "hint", "help_url",
+}
+
+// WriteCSV : すべてのレポートの指摘を1行1件のCSVとして書き込む
+func (reports *Reports) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, items := range [][]Report{reports.FatalItems, reports.ErrorItems, reports.WarningItems, reports.SuccessItems} {
+		for _, r := range items {
+			for _, f := range r.Findings {
+				record := []string{
+					r.Filename, strconv.Itoa(int(r.StatusCode)), f.Source, f.ID, f.Severity,
+					f.Sheet, positive(f.Row), positive(f.Index), f.Column, f.Cell, f.Field, f.Message, f.Details, f.Hint, f.HelpURL,
+				}
+				if err := cw.Write(record); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// positive : CSVに書き込む番号。0以下なら空欄
+func positive(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
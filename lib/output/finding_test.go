@@ -0,0 +1,129 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinding_String(t *testing.T) {
+	tests := []struct {
+		name    string
+		finding Finding
+		want    string
+	}{
+		{
+			name:    "ローカル検証はルールIDを添える",
+			finding: Finding{Source: SourceLocal, ID: "sort-order", Message: "並び順が違います"},
+			want:    "[sort-order] 並び順が違います",
+		},
+		{
+			name: "PNSearchは詳細と行番号、項目名を添える",
+			finding: Finding{Source: SourceServer, ID: "品番が見つかりません", Message: "品番が見つかりません",
+				Details: "PN-1", Sheet: "入力Ⅰ", Row: 3, Field: "Pid"},
+			want: "品番が見つかりません: PN-1 [3行目:Pid]",
+		},
+		{
+			name:    "PNSearchの詳細がなければ項目名だけ",
+			finding: Finding{Source: SourceServer, Message: "品番が見つかりません", Field: "Pid"},
+			want:    "品番が見つかりません: [Pid]",
+		},
+		{
+			name: "PNSearchの自動修正はそれを示す",
+			finding: Finding{Source: SourceOverride, Message: "品名を修正しました",
+				Details: "ボルト", Row: 2, Field: "Name"},
+			want: "PNSearchの自動修正: 品名を修正しました: ボルト [2行目:Name]",
+		},
+		{
+			name:    "行番号が分からなければ明細の番号",
+			finding: Finding{Source: SourceServer, Message: "品番が見つかりません", Index: 12, Field: "Pid"},
+			want:    "品番が見つかりません: [明細12:Pid]",
+		},
+		{
+			name:    "PNSearchのメッセージのみ",
+			finding: Finding{Source: SourceServer, Message: "エラーがあります"},
+			want:    "エラーがあります",
+		},
+		{
+			name:    "システムエラーはメッセージのみ",
+			finding: Finding{Source: SourceSystem, Message: "API通信エラー: timeout", Row: 2},
+			want:    "API通信エラー: timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.finding.String())
+		})
+	}
+}
+
+func TestStatusCode_Severity(t *testing.T) {
+	tests := []struct {
+		code StatusCode
+		want string
+	}{
+		{200, SeverityInfo},
+		{300, SeverityWarning},
+		{404, SeverityError},
+		{500, SeverityFatal},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.code.Severity(), "StatusCode(%d)", tt.code)
+	}
+}
+
+func TestReport_WorstStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		findings []Finding
+		want     StatusCode
+	}{
+		{"指摘なし", nil, 200},
+		{"参考情報のみ", []Finding{{Severity: SeverityInfo}}, 200},
+		{"1回目のErrorと2回目のWarning", []Finding{
+			{Source: SourceServer, Severity: SeverityError},
+			{Source: SourceOverride, Severity: SeverityWarning},
+		}, 400},
+		{"Fatalが最も重い", []Finding{{Severity: SeverityWarning}, {Severity: SeverityFatal}}, 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Report{Findings: tt.findings}.WorstStatus())
+		})
+	}
+}
+
+func TestReport_ProblemsAndNotes(t *testing.T) {
+	r := Report{Findings: []Finding{
+		{Severity: SeverityError, Message: "a"},
+		{Severity: SeverityInfo, Message: "b"},
+		{Severity: SeverityWarning, Message: "c"},
+	}}
+	assert.Equal(t, []Finding{r.Findings[0], r.Findings[2]}, r.Problems())
+	assert.Equal(t, []Finding{r.Findings[1]}, r.Notes())
+}
+
+func TestReports_WriteCSV(t *testing.T) {
+	var reports Reports
+	require.NoError(t, reports.Classify(Report{
+		Filename: "error.xlsx", StatusCode: 400,
+		Findings: []Finding{
+			{Source: SourceLocal, ID: "sort-order", Severity: SeverityError, Sheet: "入力Ⅰ",
+				Row: 4, Column: "J", Cell: "J4", Message: "要望納期が前の行より前です"},
+			{Source: SourceServer, Severity: SeverityError, Row: 2, Field: "Pid",
+				Message: "品番が見つかりません", Details: "PN-1, PN-2", Hint: "品番を検索してください", HelpURL: "http://wiki/pid"},
+			{Source: SourceServer, Severity: SeverityError, Index: 12, Message: "品名が違います"},
+		},
+	}))
+	require.NoError(t, reports.Classify(Report{Filename: "success.xlsx", StatusCode: 200}))
+
+	var buf bytes.Buffer
+	require.NoError(t, reports.WriteCSV(&buf))
+	want := "file,status,source,id,severity,sheet,row,index,column,cell,field,message,details,hint,help_url\n" +
+		"error.xlsx,400,local,sort-order,error,入力Ⅰ,4,,J,J4,,要望納期が前の行より前です,,,\n" +
+		"error.xlsx,400,server,,error,,2,,,,Pid,品番が見つかりません,\"PN-1, PN-2\",品番を検索してください,http://wiki/pid\n" +
+		"error.xlsx,400,server,,error,,,12,,,,品名が違います,,,\n"
+	assert.Equal(t, want, buf.String())
+}
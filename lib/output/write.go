@@ -9,10 +9,13 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/template"
+
+	"pncheck/lib/i18n"
 )
 
 const (
@@ -35,14 +38,43 @@ const (
 	fatalCode              // 500
 )
 
+// Property : レポートに表示する項目名と値の組
+type Property struct {
+	Name, Value string
+}
+
+// Diff : pncheckが読み込んだ値とPNSearchが返した値の差分
+type Diff struct {
+	Location, Field, Local, Server string
+}
+
 // Report : HTMLに表示するためのデータを纏めた構造体
 // ファイル名やPNSearch表示用URLをまとめた構造体
 type Report struct {
 	Filename, Link string
-	ErrorMessages  []string
+	Path           string     `json:"-"` // 処理したファイルのパス。同名のファイルを区別する
+	OverrideLink   string     // PNSearchが品名、型式、単位を修正した要求票のURL
+	Findings       []Finding  // ローカル検証とPNSearchの指摘、読み込みなどのエラー
+	Changes        []string   // pncheckが正規化などで書き換えた値
+	FileProperties []Property // ファイル名から読み取った日付や製番
+	Suppressed     []string   // 抑制設定ファイルによってステータスから除外した指摘
+	FixedFile      string     // -fix で作成した修正版のパス
+	Fixes          []string   // 修正版で書き換えた内容
+	Diffs          []Diff     // PNSearchが返した要求票との差分
 	StatusCode
-	// []ErrorRecord  // TODO 保存しておくと後で役立つかも？
-	// Sheet // TODO 保存しておくと後で役立つかも？シートの修正とか。
+}
+
+// FixedURL : レポートから修正版を開くためのURL
+func (r Report) FixedURL() string {
+	p, err := filepath.Abs(r.FixedFile)
+	if err != nil {
+		p = r.FixedFile
+	}
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // Windowsのドライブ文字から始まるパス
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
 }
 
 // Reports : 各Report をステータスコードによって分類し、実行時間を格納しておく構造体
@@ -65,7 +97,10 @@ type Reports struct {
 
 // Publish : report.tmplを基にReportsをHTMLファイルとして出力する
 func (reports *Reports) Publish(outputPath string) error {
-	tmpl, err := template.ParseFS(templateFS, templateFile)
+	tmpl, err := template.New(templateFile).Funcs(template.FuncMap{
+		"T":    i18n.T,       // ラベルを表示する言語に翻訳する
+		"Lang": i18n.Current, // html要素のlang属性
+	}).ParseFS(templateFS, templateFile)
 	if err != nil {
 		return err
 	}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"  // assertを使うと簡潔に書けます
@@ -85,3 +87,57 @@ func TestModifyFilePath(t *testing.T) {
 		})
 	}
 }
+
+func TestPublish(t *testing.T) {
+	dir := setupTestDir(t)
+	outputPath := filepath.Join(dir, "report.html")
+
+	reports := Reports{Version: "v0.0.0"}
+	props := []Property{{Name: "製番", Value: "123456789000"}, {Name: "補足", Value: ""}}
+	require.NoError(t, reports.Classify(Report{
+		Filename: "warning.xlsx", StatusCode: 300,
+		Findings: []Finding{
+			{Source: SourceServer, Severity: SeverityWarning, Message: "警告メッセージ", Details: "詳細", Row: 3, Field: "品番"},
+			{Source: SourceLocal, ID: "excel-sum", Severity: SeverityInfo, Message: "合計金額の確認: 一致"},
+		},
+		Changes:        []string{"入力Ⅰ E2 品番: \"ＰＮ\" → \"PN\""},
+		FileProperties: props,
+	}))
+	require.NoError(t, reports.Classify(Report{
+		Filename: "success.xlsx", StatusCode: 200, FileProperties: props,
+	}))
+	require.NoError(t, reports.Classify(Report{
+		Filename: "error.xlsx", StatusCode: 400,
+		Link:         "http://localhost/first",
+		OverrideLink: "http://localhost/override",
+		Findings: []Finding{
+			{Source: SourceServer, Severity: SeverityError, Message: "品番が見つかりません",
+				Hint: "品番を検索してください", HelpURL: "http://wiki/pid"},
+			{Source: SourceOverride, Severity: SeverityWarning, Message: "品名を修正しました"},
+		},
+	}))
+
+	require.NoError(t, reports.Publish(outputPath))
+	b, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	html := string(b)
+	assert.Contains(t, html, "警告メッセージ: 詳細 [3行目:品番]")
+	assert.Contains(t, html, "参考情報 (1件)")
+	assert.Contains(t, html, "[excel-sum] 合計金額の確認: 一致")
+	assert.Contains(t, html, "正規化: 入力Ⅰ E2 品番")
+	assert.Contains(t, html, "製番: 123456789000")
+	assert.NotContains(t, html, "補足:", "空の項目は表示しない")
+	assert.Equal(t, 1, strings.Count(html, "error.xlsx"), "1回目と2回目のPOSTを1つのレポートにまとめる")
+	assert.Contains(t, html, "PNSearchの自動修正: 品名を修正しました")
+	assert.Contains(t, html, `href="http://localhost/override"`)
+	assert.Contains(t, html, "対処: 品番を検索してください")
+	assert.Contains(t, html, `href="http://wiki/pid"`)
+}
+
+func TestReport_FixedURL(t *testing.T) {
+	r := Report{FixedFile: "/path/to/要求票.pncheck.xlsx"}
+	want := "file:///path/to/%E8%A6%81%E6%B1%82%E7%A5%A8.pncheck.xlsx"
+	if got := r.FixedURL(); got != want {
+		t.Errorf("FixedURL() = %q, want %q", got, want)
+	}
+}
@@ -8,7 +8,11 @@ import (
 	"time"
 
 	"pncheck/lib"
+	"pncheck/lib/config"
+	"pncheck/lib/hint"
+	"pncheck/lib/i18n"
 	"pncheck/lib/input"
+	"pncheck/lib/output"
 )
 
 //go:embed winres/icon.png
@@ -22,23 +26,45 @@ const (
 
 func main() {
 	// コマンドライン引数を解析
-	filePaths, verboseLevel, err := lib.ParseArguments(VERSION)
+	opts, err := lib.ParseArguments(VERSION)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
+	// 設定ファイルの読み込み。ファイルがなければ既定値
+	cfg, err := config.Load(opts.ConfigPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// 指摘ごとの対処方法。設定ファイルで指定したファイルで既定のヒントを上書きする
+	hints, err := hint.Load(cfg.Hints.File)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// ローカル検証ルールに設定を反映する
+	engine, err := input.NewEngine(cfg)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if opts.ListRules {
+		fmt.Print(engine.Describe())
+		return
+	}
+
 	// ServerAddress はビルド時 -ldflags で注入される。未設定なら起動時に即終了
 	if input.ServerAddress == "" {
 		log.Fatalln(
-			"APIサーバーアドレスが未設定です。ビルド時に設定する必要があります。\n" +
+			i18n.T("APIサーバーアドレスが未設定です。ビルド時に設定する必要があります。\n") +
 				`$ go build -ldflags="-X pncheck/lib/input.ServerAddress=http://localhost:8080"`,
 		)
 	}
 
 	// 各ファイルを処理
-	reports, err := lib.ProcessExcelFiles(filePaths, verboseLevel)
+	reports, err := lib.ProcessExcelFiles(opts.FilePaths, opts.VerboseLevel, cfg, engine, hints, opts.Fix)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "レポートファイルの出力に失敗しました: %v\n", err)
+		fmt.Fprint(os.Stderr, i18n.T("レポートファイルの出力に失敗しました: %v\n", err))
 	}
 	reports.Version = VERSION
 	reports.BuildTime = input.BuildTime
@@ -46,16 +72,35 @@ func main() {
 	reports.ServerAddress = input.ServerAddress
 	reports.RawIconContent = iconContent // main.goで埋め込んだアイコンコンテンツを渡す
 
-	if verboseLevel > 0 {
+	if opts.VerboseLevel > 0 {
 		b, err := reports.ToJSON()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "JSONの標準出力に失敗しました: %v\n", err)
+			fmt.Fprint(os.Stderr, i18n.T("JSONの標準出力に失敗しました: %v\n", err))
 		}
 		fmt.Printf("%s\n", string(b)) // 標準出力
 	}
 
 	err = reports.Publish(outputPath) // HTML出力
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "レポートファイルの出力に失敗しました: %v\n", err)
+		fmt.Fprint(os.Stderr, i18n.T("レポートファイルの出力に失敗しました: %v\n", err))
+	}
+
+	if opts.CSVPath != "" {
+		if err := writeCSV(&reports, opts.CSVPath); err != nil {
+			fmt.Fprint(os.Stderr, i18n.T("CSVファイルの出力に失敗しました: %v\n", err))
+		}
+	}
+}
+
+// writeCSV : 指摘の一覧をCSVファイルとして出力する
+func writeCSV(reports *output.Reports, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := reports.WriteCSV(f); err != nil {
+		f.Close()
+		return err
 	}
+	return f.Close()
 }